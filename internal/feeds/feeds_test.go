@@ -0,0 +1,60 @@
+package feeds
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cveapi/internal/files"
+)
+
+func TestIngestRecordsRejectsMalformedCveID(t *testing.T) {
+	base := t.TempDir()
+	p := NewPuller(base, nil, nil)
+
+	rec := files.CVERecord{
+		CveMetadata: files.CVEMetadata{CveID: "CVE-../../../../etc/cron.d/evil"},
+	}
+	rec.Containers.CNA.Descriptions = []files.LocalizedDescription{{Lang: "en", Value: "test"}}
+
+	written, err := p.ingestRecords(context.Background(), []files.CVERecord{rec})
+	if err != nil {
+		t.Fatalf("ingestRecords: %v", err)
+	}
+	if written != 0 {
+		t.Fatalf("expected 0 records written for a malformed CVE ID, got %d", written)
+	}
+
+	if _, err := os.Stat(filepath.Join(base, "etc", "cron.d", "evil.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no file written outside BasePath, stat err = %v", err)
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		t.Fatalf("readdir %s: %v", base, err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected BasePath to stay empty, found %v", entries)
+	}
+}
+
+func TestIngestRecordsAcceptsWellFormedCveID(t *testing.T) {
+	base := t.TempDir()
+	p := NewPuller(base, nil, nil)
+
+	rec := files.CVERecord{
+		CveMetadata: files.CVEMetadata{CveID: "CVE-2026-12345"},
+	}
+	rec.Containers.CNA.Descriptions = []files.LocalizedDescription{{Lang: "en", Value: "test"}}
+
+	written, err := p.ingestRecords(context.Background(), []files.CVERecord{rec})
+	if err != nil {
+		t.Fatalf("ingestRecords: %v", err)
+	}
+	if written != 1 {
+		t.Fatalf("expected 1 record written, got %d", written)
+	}
+	if _, err := os.Stat(filepath.Join(base, "CVE-2026-12345.json")); err != nil {
+		t.Fatalf("expected record written under BasePath: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package feeds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+
+	"cveapi/internal/files"
+)
+
+// Notifier is notified when an ingested CVE record crosses the configured
+// severity threshold. Implementations should return quickly; long-running
+// notifiers should hand off to a queue internally.
+type Notifier interface {
+	Notify(ctx context.Context, rec files.CVERecord, score float64) error
+}
+
+// WebhookNotifier POSTs a small JSON payload to a configured URL whenever a
+// severity-crossing record is ingested.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	CveID     string  `json:"cveId"`
+	Title     string  `json:"title"`
+	BaseScore float64 `json:"baseScore"`
+}
+
+// NewWebhookNotifier returns a WebhookNotifier posting to url using the
+// provided client, or http.DefaultClient if client is nil.
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WebhookNotifier{URL: url, Client: client}
+}
+
+// Notify implements Notifier.
+func (w *WebhookNotifier) Notify(ctx context.Context, rec files.CVERecord, score float64) error {
+	payload := webhookPayload{
+		CveID:     rec.CveMetadata.CveID,
+		Title:     rec.Containers.CNA.Title,
+		BaseScore: score,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request to %s: %w", w.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", w.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier is a minimal stub that sends a plaintext alert via SMTP.
+// It is intended as a starting point for operators who want email alerts
+// rather than a webhook; wire up auth/TLS as needed for your SMTP provider.
+type EmailNotifier struct {
+	Addr string
+	From string
+	To   []string
+}
+
+// NewEmailNotifier returns an EmailNotifier sending from `from` to `to` via
+// the SMTP server at addr (host:port).
+func NewEmailNotifier(addr, from string, to []string) *EmailNotifier {
+	return &EmailNotifier{Addr: addr, From: from, To: to}
+}
+
+// Notify implements Notifier.
+func (e *EmailNotifier) Notify(ctx context.Context, rec files.CVERecord, score float64) error {
+	msg := fmt.Sprintf("Subject: [cveapi] %s crossed severity threshold (score %.1f)\r\n\r\n%s\r\n",
+		rec.CveMetadata.CveID, score, rec.Containers.CNA.Title)
+
+	if err := smtp.SendMail(e.Addr, nil, e.From, e.To, []byte(msg)); err != nil {
+		return fmt.Errorf("send alert email for %s: %w", rec.CveMetadata.CveID, err)
+	}
+	return nil
+}
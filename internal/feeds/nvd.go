@@ -0,0 +1,191 @@
+package feeds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"cveapi/internal/files"
+)
+
+const nvdDefaultBaseURL = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+
+// nvdResponse is the subset of the NVD JSON API 2.0 response shape we need.
+type nvdResponse struct {
+	ResultsPerPage  int `json:"resultsPerPage"`
+	StartIndex      int `json:"startIndex"`
+	TotalResults    int `json:"totalResults"`
+	Vulnerabilities []struct {
+		CVE nvdCVE `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+type nvdCVE struct {
+	ID           string    `json:"id"`
+	Published    string    `json:"published"`
+	LastModified string    `json:"lastModified"`
+	Descriptions []nvdDesc `json:"descriptions"`
+	Metrics      struct {
+		CvssMetricV31 []nvdCVSSMetric `json:"cvssMetricV31"`
+		CvssMetricV30 []nvdCVSSMetric `json:"cvssMetricV30"`
+		CvssMetricV2  []nvdCVSSMetric `json:"cvssMetricV2"`
+	} `json:"metrics"`
+}
+
+type nvdDesc struct {
+	Lang  string `json:"lang"`
+	Value string `json:"value"`
+}
+
+type nvdCVSSMetric struct {
+	CvssData struct {
+		Version      string  `json:"version"`
+		VectorString string  `json:"vectorString"`
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+	} `json:"cvssData"`
+}
+
+// fetchNVD pages through the NVD JSON API 2.0 for CVEs last modified in
+// (since, now], returning the converted records and the new window end
+// (the time fetchNVD was started, used as the next pull's start).
+func fetchNVD(ctx context.Context, client *http.Client, src SourceConfig, since time.Time) ([]files.CVERecord, time.Time, error) {
+	base := src.BaseURL
+	if base == "" {
+		base = nvdDefaultBaseURL
+	}
+	pageSize := src.PageSize
+	if pageSize <= 0 {
+		pageSize = 200
+	}
+
+	end := time.Now().UTC()
+
+	var records []files.CVERecord
+	startIndex := 0
+
+	for {
+		q := url.Values{}
+		q.Set("resultsPerPage", strconv.Itoa(pageSize))
+		q.Set("startIndex", strconv.Itoa(startIndex))
+		if !since.IsZero() {
+			q.Set("lastModStartDate", since.Format(time.RFC3339))
+			q.Set("lastModEndDate", end.Format(time.RFC3339))
+		}
+
+		var page nvdResponse
+		if err := getJSONWithBackoff(ctx, client, base+"?"+q.Encode(), src.APIKey, "apiKey", &page); err != nil {
+			return nil, since, fmt.Errorf("nvd page at offset %d: %w", startIndex, err)
+		}
+
+		for _, v := range page.Vulnerabilities {
+			records = append(records, convertNVD(v.CVE))
+		}
+
+		startIndex += len(page.Vulnerabilities)
+		if len(page.Vulnerabilities) == 0 || startIndex >= page.TotalResults {
+			break
+		}
+	}
+
+	return records, end, nil
+}
+
+func convertNVD(c nvdCVE) files.CVERecord {
+	rec := files.CVERecord{
+		DataType:    "CVE_RECORD",
+		DataVersion: "5.0",
+		CveMetadata: files.CVEMetadata{
+			CveID: c.ID,
+		},
+	}
+	if t, err := time.Parse(time.RFC3339, c.Published); err == nil {
+		rec.CveMetadata.DatePublished = files.LocalTime{Time: t}
+	}
+	if t, err := time.Parse(time.RFC3339, c.LastModified); err == nil {
+		rec.CveMetadata.DateUpdated = files.LocalTime{Time: t}
+	}
+
+	for _, d := range c.Descriptions {
+		rec.Containers.CNA.Descriptions = append(rec.Containers.CNA.Descriptions, files.LocalizedDescription{
+			Lang: d.Lang, Value: d.Value,
+		})
+		if d.Lang == "en" {
+			rec.Containers.CNA.Title = d.Value
+		}
+	}
+
+	metric := files.Metric{}
+	switch {
+	case len(c.Metrics.CvssMetricV31) > 0:
+		m := c.Metrics.CvssMetricV31[0].CvssData
+		metric.CVSSV31 = &files.CVSS{Version: m.Version, BaseScore: m.BaseScore, VectorString: m.VectorString, BaseSeverity: m.BaseSeverity}
+	case len(c.Metrics.CvssMetricV30) > 0:
+		m := c.Metrics.CvssMetricV30[0].CvssData
+		metric.CVSSV30 = &files.CVSS{Version: m.Version, BaseScore: m.BaseScore, VectorString: m.VectorString, BaseSeverity: m.BaseSeverity}
+	case len(c.Metrics.CvssMetricV2) > 0:
+		m := c.Metrics.CvssMetricV2[0].CvssData
+		metric.CVSSV20 = &files.CVSS20{Version: m.Version, BaseScore: m.BaseScore, VectorString: m.VectorString}
+	}
+	if metric != (files.Metric{}) {
+		rec.Containers.CNA.Metrics = append(rec.Containers.CNA.Metrics, metric)
+	}
+
+	return rec
+}
+
+// getJSONWithBackoff fetches url, retrying transient failures (5xx, rate
+// limiting) with exponential backoff, and decodes the JSON body into out.
+func getJSONWithBackoff(ctx context.Context, client *http.Client, reqURL, apiKey, apiKeyHeader string, out interface{}) error {
+	const maxAttempts = 5
+	backoff := 500 * time.Millisecond
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		if apiKey != "" {
+			req.Header.Set(apiKeyHeader, apiKey)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request %s: %w", reqURL, err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("request %s: status %d", reqURL, resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			resp.Body.Close()
+			return fmt.Errorf("request %s: status %d", reqURL, resp.StatusCode)
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(out)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("decode response from %s: %w", reqURL, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", maxAttempts, lastErr)
+}
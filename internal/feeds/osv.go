@@ -0,0 +1,184 @@
+package feeds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cveapi/internal/files"
+)
+
+const osvDefaultBaseURL = "https://api.osv.dev/v1/query"
+
+// osvVuln is the subset of the OSV.dev vulnerability schema we need.
+type osvVuln struct {
+	ID        string   `json:"id"`
+	Aliases   []string `json:"aliases"`
+	Summary   string   `json:"summary"`
+	Details   string   `json:"details"`
+	Modified  string   `json:"modified"`
+	Published string   `json:"published"`
+	Severity  []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	Affected []struct {
+		Package struct {
+			Name      string `json:"name"`
+			Ecosystem string `json:"ecosystem"`
+		} `json:"package"`
+	} `json:"affected"`
+}
+
+type osvQueryPage struct {
+	Vulns []osvVuln `json:"vulns"`
+}
+
+// fetchOSV queries OSV.dev for vulnerabilities modified after since. It
+// sends a single query and converts only the page of results that query
+// returns; further pages are not requested, so results beyond the first
+// page are not ingested.
+func fetchOSV(ctx context.Context, client *http.Client, src SourceConfig, since time.Time) ([]files.CVERecord, time.Time, error) {
+	base := src.BaseURL
+	if base == "" {
+		base = osvDefaultBaseURL
+	}
+
+	reqBody := map[string]interface{}{}
+	if !since.IsZero() {
+		reqBody["modified_since"] = since.Format(time.RFC3339)
+	}
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, since, fmt.Errorf("marshal osv query: %w", err)
+	}
+
+	var page osvQueryPage
+	if err := postJSONWithBackoff(ctx, client, base, body, &page); err != nil {
+		return nil, since, fmt.Errorf("osv query: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var records []files.CVERecord
+	for _, v := range page.Vulns {
+		if cveID, ok := osvCveAlias(v); ok {
+			records = append(records, convertOSV(v, cveID))
+		}
+	}
+
+	return records, now, nil
+}
+
+// osvCveAlias returns the CVE ID an OSV record maps to, if any. OSV entries
+// are keyed by their own ID scheme (GHSA-..., PYSEC-...) but list CVE IDs as
+// aliases; we only care about records we can map back to a CVE.
+func osvCveAlias(v osvVuln) (string, bool) {
+	if len(v.ID) > 4 && v.ID[:4] == "CVE-" {
+		return v.ID, true
+	}
+	for _, a := range v.Aliases {
+		if len(a) > 4 && a[:4] == "CVE-" {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+func convertOSV(v osvVuln, cveID string) files.CVERecord {
+	rec := files.CVERecord{
+		DataType:    "CVE_RECORD",
+		DataVersion: "5.0",
+		CveMetadata: files.CVEMetadata{
+			CveID: cveID,
+		},
+	}
+	if t, err := time.Parse(time.RFC3339, v.Published); err == nil {
+		rec.CveMetadata.DatePublished = files.LocalTime{Time: t}
+	}
+	if t, err := time.Parse(time.RFC3339, v.Modified); err == nil {
+		rec.CveMetadata.DateUpdated = files.LocalTime{Time: t}
+	}
+
+	title := v.Summary
+	if title == "" {
+		title = v.Details
+	}
+	rec.Containers.CNA.Title = title
+	if title != "" {
+		rec.Containers.CNA.Descriptions = append(rec.Containers.CNA.Descriptions, files.LocalizedDescription{
+			Lang: "en", Value: title,
+		})
+	}
+
+	for _, a := range v.Affected {
+		rec.Containers.CNA.Affected = append(rec.Containers.CNA.Affected, files.Affected{
+			Vendor:  a.Package.Ecosystem,
+			Product: a.Package.Name,
+		})
+	}
+
+	for _, sev := range v.Severity {
+		if sev.Type != "CVSS_V3" {
+			continue
+		}
+		// OSV stores the vector string, not the parsed base score; record
+		// the vector so downstream consumers can compute/display it, and
+		// leave BaseScore at zero rather than guessing.
+		rec.Containers.CNA.Metrics = append(rec.Containers.CNA.Metrics, files.Metric{
+			CVSSV31: &files.CVSS{Version: "3.1", VectorString: sev.Score},
+		})
+	}
+
+	return rec
+}
+
+func postJSONWithBackoff(ctx context.Context, client *http.Client, reqURL string, body []byte, out interface{}) error {
+	const maxAttempts = 5
+	backoff := 500 * time.Millisecond
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("request %s: %w", reqURL, err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("request %s: status %d", reqURL, resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			resp.Body.Close()
+			return fmt.Errorf("request %s: status %d", reqURL, resp.StatusCode)
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(out)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("decode response from %s: %w", reqURL, err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", maxAttempts, lastErr)
+}
@@ -1,11 +1,20 @@
 package files
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
+// ErrInvalidCVE is wrapped by Validate when a parsed CVERecord is missing
+// fields a well-formed CVE Record Format v5.x document always has, so
+// callers can errors.Is(err, ErrInvalidCVE) instead of string-matching the
+// reason. ReadFile itself does not call Validate - callers that ingest
+// records (as opposed to ones just searching/listing already-stored files)
+// are expected to validate before indexing.
+var ErrInvalidCVE = errors.New("invalid CVE record")
+
 type CVERecord struct {
 	DataType    string      `json:"dataType"`
 	DataVersion string      `json:"dataVersion"`
@@ -13,6 +22,19 @@ type CVERecord struct {
 	Containers  Containers  `json:"containers"`
 }
 
+// Validate reports whether r has the minimum fields a CVE Record needs to be
+// usable: an ID to index it under and a CNA container with a description.
+// It does not attempt to validate the full CVE 5.x schema.
+func (r CVERecord) Validate() error {
+	if r.CveMetadata.CveID == "" {
+		return fmt.Errorf("%w: missing cveMetadata.cveId", ErrInvalidCVE)
+	}
+	if len(r.Containers.CNA.Descriptions) == 0 {
+		return fmt.Errorf("%w: %s has no containers.cna.descriptions", ErrInvalidCVE, r.CveMetadata.CveID)
+	}
+	return nil
+}
+
 type CVEMetadata struct {
 	CveID             string    `json:"cveId"`
 	AssignerOrgID     string    `json:"assignerOrgId"`
@@ -3,13 +3,20 @@ package files
 
 import (
 	"container/heap"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/errgroup"
 )
 
 // Logger is used for warnings and diagnostic messages in this package.
@@ -43,6 +50,73 @@ func TraverseDir(root string, handler FileHandler) error {
 	})
 }
 
+// TraverseDirParallel walks root on one goroutine, feeding file entries to a
+// pool of workers goroutines that each invoke handler. Unlike TraverseDir it
+// does not guarantee handler calls happen in filesystem order, which is fine
+// for the indexing use case (each file is processed independently). The
+// first error returned by either the walk or any handler call cancels the
+// remaining work and is returned. If workers <= 0 a single worker is used.
+//
+// skipDir, if non-nil, is consulted for every directory the walk descends
+// into; a true return prunes that subtree (filepath.SkipDir) instead of
+// feeding its files to handler. Pass nil to walk everything under root.
+func TraverseDirParallel(root string, workers int, skipDir func(path string) bool, handler FileHandler) error {
+	if handler == nil {
+		return nil
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	type entry struct {
+		path string
+		d    fs.DirEntry
+	}
+
+	entries := make(chan entry, workers*2)
+
+	g, ctx := errgroup.WithContext(context.Background())
+
+	g.Go(func() error {
+		defer close(entries)
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if skipDir != nil && skipDir(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			select {
+			case entries <- entry{path: path, d: d}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	})
+
+	for i := 0; i < workers; i++ {
+		g.Go(func() error {
+			for e := range entries {
+				if err := handler(e.path, e.d); err != nil {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+				}
+			}
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
 // ReadFile reads and unmarshals a CVE JSON file. Returns an error on failure.
 func ReadFile(path string) (CVERecord, error) {
 	var rec CVERecord
@@ -82,6 +156,13 @@ func (h *_minHeap) Pop() interface{} {
 	return it
 }
 
+// _minHeapShard pairs a _minHeap with the mutex guarding it, used by
+// CollectLatestParallel to shard candidate tracking across workers.
+type _minHeapShard struct {
+	mu   sync.Mutex
+	heap _minHeap
+}
+
 // FindByFilename searches root for files whose filename contains the provided pattern
 // and returns the matched CVERecords. It stops on Walk errors but skips files that
 // cannot be parsed.
@@ -102,18 +183,37 @@ func FindByFilename(root, pattern string) ([]CVERecord, error) {
 	return results, err
 }
 
-// CollectLatest returns up to 'limit' CVERecords sorted by DatePublished descending.
-// If limit <= 0 it defaults to 50.
+// CollectLatest returns up to 'limit' CVERecords sorted by DatePublished
+// descending. If limit <= 0 it defaults to 50. It shards the work across
+// runtime.NumCPU() workers, each keeping its own bounded min-heap of
+// candidates as it streams files, then merges the shards' candidates to
+// pick the true overall top `limit` — see CollectLatestParallel for a
+// variant with a configurable worker count.
 func CollectLatest(root string, limit int) ([]CVERecord, error) {
+	return CollectLatestParallel(root, limit, runtime.NumCPU())
+}
+
+// CollectLatestParallel is CollectLatest with an explicit worker count, for
+// benchmarking and for callers that want to bound concurrency (e.g. to
+// avoid saturating disk I/O on the full CVE corpus).
+func CollectLatestParallel(root string, limit, workers int) ([]CVERecord, error) {
 	if limit <= 0 {
 		limit = 50
 	}
+	if workers <= 0 {
+		workers = 1
+	}
 
-	// Use package-level min-heap to keep at most `limit` items while streaming files.
-	h := &_minHeap{}
-	heap.Init(h)
+	// Each shard keeps its own bounded min-heap of at most `limit` items,
+	// guarded by its own mutex, so the common case (workers land on
+	// different shards) never contends on a single shared heap.
+	shardHeaps := make([]_minHeapShard, workers)
+	for i := range shardHeaps {
+		heap.Init(&shardHeaps[i].heap)
+	}
 
-	err := TraverseDir(root, func(path string, d fs.DirEntry) error {
+	var nextShard int32
+	err := TraverseDirParallel(root, workers, nil, func(path string, d fs.DirEntry) error {
 		rec, err := ReadFile(path)
 		if err != nil {
 			// skip files we can't read
@@ -121,31 +221,39 @@ func CollectLatest(root string, limit int) ([]CVERecord, error) {
 			return nil
 		}
 
-		heap.Push(h, &_heapItem{rec: &rec})
-		if h.Len() > limit {
-			heap.Pop(h)
+		shard := &shardHeaps[int(atomic.AddInt32(&nextShard, 1))%workers]
+		shard.mu.Lock()
+		heap.Push(&shard.heap, &_heapItem{rec: &rec})
+		if shard.heap.Len() > limit {
+			heap.Pop(&shard.heap)
 		}
+		shard.mu.Unlock()
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	// extract items from heap into slice and sort descending by DatePublished
-	n := h.Len()
-	out := make([]CVERecord, 0, n)
-	for h.Len() > 0 {
-		it := heap.Pop(h).(*_heapItem)
-		out = append(out, *it.rec)
+	// Merge: each shard already kept its own top `limit`, so the union of
+	// shards has at most workers*limit candidates; one more sort-and-trim
+	// over that (small) set yields the true overall top `limit`.
+	var candidates []CVERecord
+	for i := range shardHeaps {
+		h := &shardHeaps[i].heap
+		for h.Len() > 0 {
+			it := heap.Pop(h).(*_heapItem)
+			candidates = append(candidates, *it.rec)
+		}
 	}
 
-	// out currently is ascending by DatePublished because we popped from min-heap,
-	// so reverse to get descending order.
-	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
-		out[i], out[j] = out[j], out[i]
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].CveMetadata.DatePublished.After(candidates[j].CveMetadata.DatePublished)
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
 	}
 
-	return out, nil
+	return candidates, nil
 }
 
 // TokenizeFileFromPath reads file at path and tokenizes its content as a string.
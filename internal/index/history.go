@@ -0,0 +1,175 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cveapi/internal/files"
+)
+
+// History returns every version recorded for id, oldest first, as decoded
+// CVERecords. It reflects exactly what Index.Index has appended to the
+// version chain: a version is appended whenever a record is indexed with a
+// cveMetadata.dateUpdated that differs from the one currently stored.
+func (idx *Index) History(id string) ([]files.CVERecord, error) {
+	var out []files.CVERecord
+	err := idx.store.ForEachVersion(id, func(_ time.Time, data []byte) error {
+		var rec files.CVERecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("decode version of %s: %w", id, err)
+		}
+		out = append(out, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no version history for %s", id)
+	}
+	return out, nil
+}
+
+// GetAt returns the version of id that was current at time at: the latest
+// recorded version whose dateUpdated is not after at. It returns an error
+// if id has no version that old.
+func (idx *Index) GetAt(id string, at time.Time) (files.CVERecord, error) {
+	var best *files.CVERecord
+	err := idx.store.ForEachVersion(id, func(versionAt time.Time, data []byte) error {
+		if versionAt.After(at) {
+			return nil
+		}
+		var rec files.CVERecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("decode version of %s: %w", id, err)
+		}
+		best = &rec
+		return nil
+	})
+	if err != nil {
+		return files.CVERecord{}, err
+	}
+	if best == nil {
+		return files.CVERecord{}, fmt.Errorf("no version of %s as of %s", id, at.Format(time.RFC3339))
+	}
+	return *best, nil
+}
+
+// ScoreChange describes a CVSS base-score shift between two versions of a
+// record, identified by the CVSS version string (e.g. "3.1").
+type ScoreChange struct {
+	CVSSVersion string  `json:"cvssVersion"`
+	From        float64 `json:"from"`
+	To          float64 `json:"to"`
+}
+
+// DescriptionChange captures an edit to one of a record's descriptions,
+// matched by language.
+type DescriptionChange struct {
+	Lang string `json:"lang"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Diff is the structured delta between two versions of a CVE record.
+type Diff struct {
+	ID                string              `json:"id"`
+	From              time.Time           `json:"from"`
+	To                time.Time           `json:"to"`
+	AddedReferences   []files.Reference   `json:"addedReferences,omitempty"`
+	RemovedReferences []files.Reference   `json:"removedReferences,omitempty"`
+	ScoreChanges      []ScoreChange       `json:"scoreChanges,omitempty"`
+	DescriptionEdits  []DescriptionChange `json:"descriptionChanges,omitempty"`
+}
+
+// Diff compares the versions of id as of a and b (see GetAt) and returns
+// the structured delta between them: references added/removed, CVSS score
+// changes, and description edits. a and b may be given in either order.
+func (idx *Index) Diff(id string, a, b time.Time) (*Diff, error) {
+	recA, err := idx.GetAt(id, a)
+	if err != nil {
+		return nil, fmt.Errorf("resolve version at %s: %w", a.Format(time.RFC3339), err)
+	}
+	recB, err := idx.GetAt(id, b)
+	if err != nil {
+		return nil, fmt.Errorf("resolve version at %s: %w", b.Format(time.RFC3339), err)
+	}
+
+	d := &Diff{ID: id, From: a, To: b}
+	d.AddedReferences, d.RemovedReferences = diffReferences(recA.Containers.CNA.References, recB.Containers.CNA.References)
+	d.ScoreChanges = diffScores(recA.Containers.CNA.Metrics, recB.Containers.CNA.Metrics)
+	d.DescriptionEdits = diffDescriptions(recA.Containers.CNA.Descriptions, recB.Containers.CNA.Descriptions)
+	return d, nil
+}
+
+func diffReferences(a, b []files.Reference) (added, removed []files.Reference) {
+	inA := make(map[string]bool, len(a))
+	for _, ref := range a {
+		inA[ref.URL] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, ref := range b {
+		inB[ref.URL] = true
+		if !inA[ref.URL] {
+			added = append(added, ref)
+		}
+	}
+	for _, ref := range a {
+		if !inB[ref.URL] {
+			removed = append(removed, ref)
+		}
+	}
+	return added, removed
+}
+
+// cvssVersionScores extracts the (version label, baseScore) pairs present in
+// a metrics slice, keyed by label since at most one of CVSSV40/31/30/20 is
+// normally populated per Metric entry.
+func cvssVersionScores(metrics []files.Metric) map[string]float64 {
+	scores := make(map[string]float64)
+	for _, m := range metrics {
+		if m.CVSSV40 != nil {
+			scores["4.0"] = m.CVSSV40.BaseScore
+		}
+		if m.CVSSV31 != nil {
+			scores["3.1"] = m.CVSSV31.BaseScore
+		}
+		if m.CVSSV30 != nil {
+			scores["3.0"] = m.CVSSV30.BaseScore
+		}
+		if m.CVSSV20 != nil {
+			scores["2.0"] = m.CVSSV20.BaseScore
+		}
+	}
+	return scores
+}
+
+func diffScores(a, b []files.Metric) []ScoreChange {
+	scoresA := cvssVersionScores(a)
+	scoresB := cvssVersionScores(b)
+
+	var changes []ScoreChange
+	for version, toScore := range scoresB {
+		fromScore, hadBefore := scoresA[version]
+		if !hadBefore || fromScore != toScore {
+			changes = append(changes, ScoreChange{CVSSVersion: version, From: fromScore, To: toScore})
+		}
+	}
+	return changes
+}
+
+func diffDescriptions(a, b []files.LocalizedDescription) []DescriptionChange {
+	byLangA := make(map[string]string, len(a))
+	for _, d := range a {
+		byLangA[d.Lang] = d.Value
+	}
+
+	var edits []DescriptionChange
+	for _, d := range b {
+		if prev, ok := byLangA[d.Lang]; !ok || prev != d.Value {
+			edits = append(edits, DescriptionChange{Lang: d.Lang, From: byLangA[d.Lang], To: d.Value})
+		}
+	}
+	return edits
+}
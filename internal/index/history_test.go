@@ -0,0 +1,113 @@
+package index
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cveapi/internal/files"
+)
+
+func newTestIndex(t *testing.T) *Index {
+	t.Helper()
+	dir := t.TempDir()
+	idx, err := NewIndex(filepath.Join(dir, "bleve"), filepath.Join(dir, "store.db"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+	return idx
+}
+
+func sampleRecord(id string, updated time.Time, title string, score float64, refs ...string) files.CVERecord {
+	rec := files.CVERecord{
+		DataType:    "CVE_RECORD",
+		DataVersion: "5.0",
+		CveMetadata: files.CVEMetadata{
+			CveID:         id,
+			DatePublished: files.LocalTime{Time: updated},
+			DateUpdated:   files.LocalTime{Time: updated},
+		},
+		Containers: files.Containers{
+			CNA: files.CNA{
+				Title:        title,
+				Descriptions: []files.LocalizedDescription{{Lang: "en", Value: title}},
+				Metrics:      []files.Metric{{CVSSV31: &files.CVSS{Version: "3.1", BaseScore: score, BaseSeverity: "HIGH"}}},
+			},
+		},
+	}
+	for _, u := range refs {
+		rec.Containers.CNA.References = append(rec.Containers.CNA.References, files.Reference{URL: u})
+	}
+	return rec
+}
+
+func TestIndexHistoryGetAtAndDiff(t *testing.T) {
+	idx := newTestIndex(t)
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(24 * time.Hour)
+
+	v1 := sampleRecord("CVE-TEST-1", t0, "first description", 5.0, "https://example.com/a")
+	if err := idx.Index("CVE-TEST-1", v1); err != nil {
+		t.Fatalf("index v1: %v", err)
+	}
+
+	v2 := sampleRecord("CVE-TEST-1", t1, "second description", 8.5, "https://example.com/b")
+	if err := idx.Index("CVE-TEST-1", v2); err != nil {
+		t.Fatalf("index v2: %v", err)
+	}
+
+	hist, err := idx.History("CVE-TEST-1")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(hist) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(hist))
+	}
+	if hist[0].Containers.CNA.Title != "first description" || hist[1].Containers.CNA.Title != "second description" {
+		t.Fatalf("unexpected history order: %+v", hist)
+	}
+
+	at0, err := idx.GetAt("CVE-TEST-1", t0)
+	if err != nil {
+		t.Fatalf("GetAt t0: %v", err)
+	}
+	if at0.Containers.CNA.Title != "first description" {
+		t.Fatalf("expected first description at t0, got %q", at0.Containers.CNA.Title)
+	}
+
+	if _, err := idx.GetAt("CVE-TEST-1", t0.Add(-time.Hour)); err == nil {
+		t.Fatalf("expected error resolving a version before any history exists")
+	}
+
+	got, err := idx.Get("CVE-TEST-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	var latest files.CVERecord
+	if err := json.Unmarshal(got, &latest); err != nil {
+		t.Fatalf("unmarshal latest: %v", err)
+	}
+	if latest.Containers.CNA.Title != "second description" {
+		t.Fatalf("expected Get to return the latest version, got %q", latest.Containers.CNA.Title)
+	}
+
+	diff, err := idx.Diff("CVE-TEST-1", t0, t1)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if len(diff.ScoreChanges) != 1 || diff.ScoreChanges[0].From != 5.0 || diff.ScoreChanges[0].To != 8.5 {
+		t.Fatalf("unexpected score changes: %+v", diff.ScoreChanges)
+	}
+	if len(diff.AddedReferences) != 1 || diff.AddedReferences[0].URL != "https://example.com/b" {
+		t.Fatalf("unexpected added references: %+v", diff.AddedReferences)
+	}
+	if len(diff.RemovedReferences) != 1 || diff.RemovedReferences[0].URL != "https://example.com/a" {
+		t.Fatalf("unexpected removed references: %+v", diff.RemovedReferences)
+	}
+	if len(diff.DescriptionEdits) != 1 || diff.DescriptionEdits[0].To != "second description" {
+		t.Fatalf("unexpected description edits: %+v", diff.DescriptionEdits)
+	}
+}
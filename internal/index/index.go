@@ -2,19 +2,37 @@ package index
 
 import (
 	"container/heap"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"cveapi/internal/files"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// tmpCreateSuffix and tmpDeleteSuffix mark the side directories Reindex uses
+// to rebuild the index without ever leaving the process without a working
+// one: the new index is built at path+tmpCreateSuffix while the old index
+// keeps serving reads, then the old index is moved aside to
+// path+tmpDeleteSuffix and the new one is renamed into path. A crash between
+// those two renames is self-healing: NewIndex sweeps and removes any
+// leftover siblings with either suffix on startup.
+const (
+	tmpCreateSuffix = ".tmp-for-creation"
+	tmpDeleteSuffix = ".tmp-for-deletion"
 )
 
 // Index represents a search index for CVE records
 type Index struct {
+	mu    sync.RWMutex
 	index bleve.Index
-	store *Store
+	store Storage
 	path  string
 }
 
@@ -39,47 +57,136 @@ func (h *_minHeap) Pop() interface{} {
 	return it
 }
 
-// NewIndex creates a new search index at the given path
-func NewIndex(indexPath, storePath string) (*Index, error) {
-	// Create store
-	store, err := NewStore(storePath)
+// buildMapping returns the Bleve index mapping used for every index this
+// package creates, so NewIndex and Reindex never drift apart.
+func buildMapping() mapping.IndexMapping {
+	mapping := bleve.NewIndexMapping()
+
+	// Create a document mapping and ensure date fields are treated as datetime fields
+	dateMapping := bleve.NewDateTimeFieldMapping()
+	dateMapping.Store = true
+	dateMapping.IncludeInAll = false
+
+	// store CVE id for quick retrieval from stored fields
+	idMapping := bleve.NewTextFieldMapping()
+	idMapping.Store = true
+
+	// cveMetadata is a plain (non-array) object, so its fields can be added
+	// directly on its own sub-document mapping.
+	cveMetadataMapping := bleve.NewDocumentMapping()
+	cveMetadataMapping.AddFieldMappingsAt("datePublished", dateMapping)
+	cveMetadataMapping.AddFieldMappingsAt("dateUpdated", dateMapping)
+	cveMetadataMapping.AddFieldMappingsAt("dateReserved", dateMapping)
+	cveMetadataMapping.AddFieldMappingsAt("cveId", idMapping)
+
+	// Numeric/term mappings backing the structured SearchQuery filters
+	// (CVSS score/severity, vendor/product, CWE, assigner). Kept in their
+	// own variables (rather than reused across fields) since bleve field
+	// mappings are not safe to share between AddFieldMappingsAt calls.
+	scoreMapping := bleve.NewNumericFieldMapping()
+	scoreMapping.Store = true
+
+	severityMapping := bleve.NewTextFieldMapping()
+	severityMapping.Analyzer = "keyword"
+	severityMapping.Store = true
+
+	// containers.cna.metrics is a slice; a flattened dotted path passed to
+	// AddFieldMappingsAt on the root document mapping does NOT reach inside
+	// arrays/nested objects in bleve - it silently falls back to the
+	// default (standard, lower-casing, hyphen-splitting) analyzer. Fields
+	// inside arrays need their own sub-document mapping per nesting level,
+	// which bleve then applies to every element.
+	cvssMapping := bleve.NewDocumentMapping()
+	cvssMapping.AddFieldMappingsAt("baseScore", scoreMapping)
+	cvssMapping.AddFieldMappingsAt("baseSeverity", severityMapping)
+	metricsMapping := bleve.NewDocumentMapping()
+	metricsMapping.AddSubDocumentMapping("cvssV3_1", cvssMapping)
+
+	vendorMapping := bleve.NewTextFieldMapping()
+	vendorMapping.Analyzer = "keyword"
+	vendorMapping.Store = true
+
+	productMapping := bleve.NewTextFieldMapping()
+	productMapping.Analyzer = "keyword"
+	productMapping.Store = true
+
+	affectedMapping := bleve.NewDocumentMapping()
+	affectedMapping.AddFieldMappingsAt("vendor", vendorMapping)
+	affectedMapping.AddFieldMappingsAt("product", productMapping)
+
+	cweMapping := bleve.NewTextFieldMapping()
+	cweMapping.Analyzer = "keyword"
+	cweMapping.Store = true
+
+	problemTypeDescriptionsMapping := bleve.NewDocumentMapping()
+	problemTypeDescriptionsMapping.AddFieldMappingsAt("cweId", cweMapping)
+	problemTypesMapping := bleve.NewDocumentMapping()
+	problemTypesMapping.AddSubDocumentMapping("descriptions", problemTypeDescriptionsMapping)
+
+	cnaMapping := bleve.NewDocumentMapping()
+	cnaMapping.AddSubDocumentMapping("metrics", metricsMapping)
+	cnaMapping.AddSubDocumentMapping("affected", affectedMapping)
+	cnaMapping.AddSubDocumentMapping("problemTypes", problemTypesMapping)
+
+	containersMapping := bleve.NewDocumentMapping()
+	containersMapping.AddSubDocumentMapping("cna", cnaMapping)
+
+	assignerMapping := bleve.NewTextFieldMapping()
+	assignerMapping.Analyzer = "keyword"
+	assignerMapping.Store = true
+	cveMetadataMapping.AddFieldMappingsAt("assignerShortName", assignerMapping)
+
+	docMapping := bleve.NewDocumentMapping()
+	docMapping.AddSubDocumentMapping("cveMetadata", cveMetadataMapping)
+	docMapping.AddSubDocumentMapping("containers", containersMapping)
+
+	// Use the document mapping as the default so nested structs are covered
+	mapping.DefaultMapping = docMapping
+	return mapping
+}
+
+// sweepTmpSiblings removes any leftover Reindex side directories next to
+// indexPath. A process that crashes mid-rebuild can leave one of these
+// behind; removing them on startup makes the crash self-healing.
+func sweepTmpSiblings(indexPath string) {
+	_ = os.RemoveAll(indexPath + tmpCreateSuffix)
+	_ = os.RemoveAll(indexPath + tmpDeleteSuffix)
+}
+
+// NewIndex creates a new search index at indexPath, backed by the storage
+// described by storeDSN. storeDSN is either a bare filesystem path (for
+// backward compatibility, opened as a BoltDB as before) or a DSN with an
+// explicit scheme: "bolt://path", "sqlite://path", or an object-storage URL
+// such as "s3://bucket/prefix" (whose file metadata/cursors are kept in a
+// BoltDB next to indexPath). See OpenStorage for the full DSN syntax.
+func NewIndex(indexPath, storeDSN string) (*Index, error) {
+	store, err := OpenStorage(storeDSN, indexPath+".meta.db")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create store: %w", err)
+		return nil, fmt.Errorf("failed to open storage: %w", err)
 	}
 
+	return NewIndexWithStorage(indexPath, store)
+}
+
+// NewIndexWithStorage creates a new search index at indexPath on top of an
+// already-constructed Storage backend. Use this when the caller wants
+// control over backend selection/configuration beyond what a DSN string can
+// express; NewIndex is the convenience wrapper for the common case.
+func NewIndexWithStorage(indexPath string, store Storage) (*Index, error) {
+	sweepTmpSiblings(indexPath)
+
 	// Create or open index
 	var index bleve.Index
 
 	// Try to open existing index first
-	index, err = bleve.Open(indexPath)
+	index, err := bleve.Open(indexPath)
 	if err != nil {
 		// If index doesn't exist or is corrupt, remove it and create new
 		if err == bleve.ErrorIndexPathDoesNotExist || err == bleve.ErrorIndexMetaMissing {
 			// Remove any existing corrupt index
 			_ = os.RemoveAll(indexPath)
 
-			// Create a new index with a mapping that treats the published date as a date field
-			mapping := bleve.NewIndexMapping()
-
-			// Create a document mapping and ensure date fields are treated as datetime fields
-			dateMapping := bleve.NewDateTimeFieldMapping()
-			dateMapping.Store = true
-			dateMapping.IncludeInAll = false
-
-			// store CVE id for quick retrieval from stored fields
-			idMapping := bleve.NewTextFieldMapping()
-			idMapping.Store = true
-			docMapping := bleve.NewDocumentMapping()
-			// Bleve flattens JSON field names to lowercase dotted paths (see bleve check)
-			docMapping.AddFieldMappingsAt("cveMetadata.datePublished", dateMapping)
-			docMapping.AddFieldMappingsAt("cveMetadata.dateUpdated", dateMapping)
-			docMapping.AddFieldMappingsAt("cveMetadata.dateReserved", dateMapping)
-			docMapping.AddFieldMappingsAt("cveMetadata.cveId", idMapping)
-
-			// Use the document mapping as the default so nested structs are covered
-			mapping.DefaultMapping = docMapping
-
-			index, err = bleve.New(indexPath, mapping)
+			index, err = bleve.New(indexPath, buildMapping())
 			if err != nil {
 				store.Close() // Clean up store if index creation fails
 				return nil, fmt.Errorf("failed to create new index: %w", err)
@@ -97,15 +204,50 @@ func NewIndex(indexPath, storePath string) (*Index, error) {
 	}, nil
 }
 
-// Index adds a document to the search index
+// current returns the bleve.Index currently serving reads. It is safe to
+// call concurrently with Reindex.
+func (idx *Index) current() bleve.Index {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.index
+}
+
+// Index adds a document to the search index. If id has no stored document
+// yet, or doc's cveMetadata.dateUpdated differs from the one stored for id,
+// doc itself is appended as a new entry in the version history (see History)
+// before the stored document is overwritten, so Get/Search keep returning
+// only the latest version while GetAt/History/Diff can still see the
+// timeline.
 func (idx *Index) Index(id string, doc interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document: %w", err)
+	}
+	var rec files.CVERecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return fmt.Errorf("failed to decode document for versioning: %w", err)
+	}
+
+	isNewVersion := true
+	if prev, err := idx.store.Get(id); err == nil {
+		var prevRec files.CVERecord
+		if err := json.Unmarshal(prev, &prevRec); err == nil {
+			isNewVersion = !prevRec.CveMetadata.DateUpdated.Equal(rec.CveMetadata.DateUpdated)
+		}
+	}
+	if isNewVersion {
+		if err := idx.store.PutVersion(id, rec.CveMetadata.DateUpdated.Time, data); err != nil {
+			return fmt.Errorf("failed to append version history: %w", err)
+		}
+	}
+
 	// Store the full document
 	if err := idx.store.Put(id, doc); err != nil {
 		return fmt.Errorf("failed to store document: %w", err)
 	}
 
 	// Index for search
-	if err := idx.index.Index(id, doc); err != nil {
+	if err := idx.current().Index(id, doc); err != nil {
 		return fmt.Errorf("failed to index document: %w", err)
 	}
 
@@ -116,12 +258,12 @@ func (idx *Index) Index(id string, doc interface{}) error {
 func (idx *Index) Search(query string) (*bleve.SearchResult, error) {
 	q := bleve.NewQueryStringQuery(query)
 	searchRequest := bleve.NewSearchRequest(q)
-	return idx.index.Search(searchRequest)
+	return idx.current().Search(searchRequest)
 }
 
 // Delete removes a document from both the index and store.
 func (idx *Index) Delete(id string) error {
-	if err := idx.index.Delete(id); err != nil {
+	if err := idx.current().Delete(id); err != nil {
 		return fmt.Errorf("failed to delete from index: %w", err)
 	}
 	if err := idx.store.Delete(id); err != nil {
@@ -137,7 +279,7 @@ func (idx *Index) Get(id string) ([]byte, error) {
 
 // Close closes both the index and store
 func (idx *Index) Close() error {
-	if err := idx.index.Close(); err != nil {
+	if err := idx.current().Close(); err != nil {
 		return fmt.Errorf("failed to close index: %w", err)
 	}
 	if err := idx.store.Close(); err != nil {
@@ -148,46 +290,103 @@ func (idx *Index) Close() error {
 
 // Count returns the number of documents in the index
 func (idx *Index) Count() (uint64, error) {
-	return idx.index.DocCount()
+	return idx.current().DocCount()
 }
 
-// Reindex rebuilds the search index from the stored documents
-func (idx *Index) Reindex() error {
-	// First, delete all documents from the index
-	err := idx.index.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close index: %w", err)
+// Reindex rebuilds the search index from the stored documents without ever
+// leaving the process without a working index: the new index is built at a
+// side directory while idx.current() keeps serving Search/ListLatest, and
+// only swapped into place (via rename) once it's fully populated. progress,
+// if non-nil, is called after each document is indexed with the running
+// count and the best-effort total (the document count of the index being
+// replaced). ctx cancellation stops the rebuild early and leaves the
+// existing index untouched.
+func (idx *Index) Reindex(ctx context.Context, progress func(done, total int)) error {
+	oldIndex := idx.current()
+
+	total := 0
+	if count, err := oldIndex.DocCount(); err == nil {
+		total = int(count)
 	}
 
-	if err := os.RemoveAll(idx.path); err != nil {
-		return fmt.Errorf("failed to remove existing index: %w", err)
-	}
+	tmpCreate := idx.path + tmpCreateSuffix
+	tmpDelete := idx.path + tmpDeleteSuffix
+	_ = os.RemoveAll(tmpCreate)
 
-	// Recreate the index
-	mapping := bleve.NewIndexMapping()
-	index, err := bleve.New(idx.path, mapping)
+	newIndex, err := bleve.New(tmpCreate, buildMapping())
 	if err != nil {
-		return fmt.Errorf("failed to create new index: %w", err)
+		return fmt.Errorf("failed to create side index at %s: %w", tmpCreate, err)
 	}
-	idx.index = index
 
-	// Reindex all documents from store
-	return idx.store.ForEach(func(k, v []byte) error {
+	done := 0
+	walkErr := idx.store.ForEach(func(k, v []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var doc interface{}
 		if err := json.Unmarshal(v, &doc); err != nil {
-			return fmt.Errorf("failed to unmarshal document: %w", err)
+			return fmt.Errorf("failed to unmarshal document %s: %w", string(k), err)
 		}
 
-		if err := idx.index.Index(string(k), doc); err != nil {
-			return fmt.Errorf("failed to index document: %w", err)
+		if err := newIndex.Index(string(k), doc); err != nil {
+			return fmt.Errorf("failed to index document %s: %w", string(k), err)
+		}
+
+		done++
+		if progress != nil {
+			progress(done, total)
 		}
 		return nil
 	})
+	if walkErr != nil {
+		newIndex.Close()
+		_ = os.RemoveAll(tmpCreate)
+		return fmt.Errorf("failed to populate side index: %w", walkErr)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := newIndex.Close(); err != nil {
+		return fmt.Errorf("failed to close side index: %w", err)
+	}
+
+	// Keep idx.index (the old, still-open index) serving reads until both
+	// renames have succeeded, so a rename failure never leaves the process
+	// without a working index: only once the new data is in place at
+	// idx.path do we close the old handle and reopen the new one.
+	_ = os.RemoveAll(tmpDelete)
+	if err := os.Rename(idx.path, tmpDelete); err != nil {
+		return fmt.Errorf("failed to move old index aside: %w", err)
+	}
+	if err := os.Rename(tmpCreate, idx.path); err != nil {
+		if restoreErr := os.Rename(tmpDelete, idx.path); restoreErr != nil {
+			return fmt.Errorf("failed to swap side index into place: %w (restore also failed: %v)", err, restoreErr)
+		}
+		return fmt.Errorf("failed to swap side index into place: %w", err)
+	}
+
+	if err := idx.index.Close(); err != nil {
+		return fmt.Errorf("failed to close old index: %w", err)
+	}
+	_ = os.RemoveAll(tmpDelete)
+
+	reopened, err := bleve.Open(idx.path)
+	if err != nil {
+		return fmt.Errorf("failed to reopen index after swap: %w", err)
+	}
+	idx.index = reopened
+
+	return nil
 }
 
 // ListLatest returns up to `limit` CVERecords stored in the underlying Bolt store,
-// sorted by DatePublished descending. If limit <= 0 it defaults to 50.
-func (idx *Index) ListLatest(limit int) ([]files.CVERecord, error) {
+// sorted by DatePublished descending. If limit <= 0 it defaults to 50. If
+// changedSince is non-zero, only records whose cveMetadata.dateUpdated is at
+// or after changedSince are returned, so a downstream consumer can pull just
+// what changed since its last poll instead of rescanning every record.
+func (idx *Index) ListLatest(limit int, changedSince time.Time) ([]files.CVERecord, error) {
 	if limit <= 0 {
 		limit = 50
 	}
@@ -195,12 +394,17 @@ func (idx *Index) ListLatest(limit int) ([]files.CVERecord, error) {
 	// Use Bleve to fetch the latest documents sorted by CveMetadata.DatePublished.
 	// This avoids scanning the entire Bolt store on every request.
 	// If Bleve search fails for any reason, fall back to the store iteration approach.
-	q := bleve.NewMatchAllQuery()
+	var q query.Query = bleve.NewMatchAllQuery()
+	if !changedSince.IsZero() {
+		dq := bleve.NewDateRangeQuery(changedSince, time.Time{})
+		dq.SetField(dateUpdatedField)
+		q = dq
+	}
 	req := bleve.NewSearchRequestOptions(q, limit, 0, false)
 	// Sort descending by the nested date field
 	req.SortBy([]string{"-cveMetadata.datePublished"})
 
-	res, err := idx.index.Search(req)
+	res, err := idx.current().Search(req)
 	if err == nil {
 		out := make([]files.CVERecord, 0, len(res.Hits))
 		for _, hit := range res.Hits {
@@ -228,6 +432,9 @@ func (idx *Index) ListLatest(limit int) ([]files.CVERecord, error) {
 			// skip invalid entries
 			return nil
 		}
+		if !changedSince.IsZero() && rec.CveMetadata.DateUpdated.Time.Before(changedSince) {
+			return nil
+		}
 
 		heap.Push(h, &_heapItem{rec: &rec})
 		if h.Len() > limit {
@@ -253,6 +460,119 @@ func (idx *Index) ListLatest(limit int) ([]files.CVERecord, error) {
 	return out, nil
 }
 
+// VerifyIssue describes one piece of drift Verify found between the on-disk
+// corpus, the content hash recorded in FileMeta, and the documents held in
+// the store/search index.
+type VerifyIssue struct {
+	Path   string `json:"path"`
+	DocID  string `json:"docId"`
+	Reason string `json:"reason"`
+}
+
+// VerifyReport summarizes the drift a Verify run found across the corpus.
+type VerifyReport struct {
+	Checked      int           `json:"checked"`
+	Missing      []VerifyIssue `json:"missing,omitempty"`      // file is gone but metadata still references it
+	HashDrift    []VerifyIssue `json:"hashDrift,omitempty"`    // content changed since it was last indexed
+	StoreMissing []VerifyIssue `json:"storeMissing,omitempty"` // metadata points at a doc the store no longer has
+	IndexMissing []VerifyIssue `json:"indexMissing,omitempty"` // doc is in the store but not in the search index
+	Repaired     int           `json:"repaired,omitempty"`
+}
+
+// Verify walks every recorded FileMeta entry, recomputing content hashes and
+// cross-checking the store and search index, to catch drift a restart
+// wouldn't otherwise surface: files deleted out from under the index, edits
+// that slipped past change detection, and store/index divergence from a
+// crash mid-write. If repair is true, offending entries are fixed in place:
+// files that still exist are reindexed, and entries for files that no
+// longer exist are removed from the store, index, and metadata. ctx
+// cancellation stops the walk early.
+func (idx *Index) Verify(ctx context.Context, repair bool) (*VerifyReport, error) {
+	report := &VerifyReport{}
+	var toRemove []VerifyIssue
+
+	err := idx.store.ForEachMeta(func(path string, meta FileMeta) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report.Checked++
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			issue := VerifyIssue{Path: path, DocID: meta.DocID, Reason: "file missing from disk"}
+			report.Missing = append(report.Missing, issue)
+			toRemove = append(toRemove, issue)
+			return nil
+		}
+
+		if hash := HashContent(data); hash != meta.Hash {
+			report.HashDrift = append(report.HashDrift, VerifyIssue{Path: path, DocID: meta.DocID, Reason: "content hash no longer matches recorded metadata"})
+			if repair {
+				if err := idx.reindexContent(path, data, meta.DocID); err != nil {
+					return fmt.Errorf("repair %s: %w", path, err)
+				}
+				report.Repaired++
+			}
+			return nil
+		}
+
+		if _, err := idx.store.Get(meta.DocID); err != nil {
+			report.StoreMissing = append(report.StoreMissing, VerifyIssue{Path: path, DocID: meta.DocID, Reason: "document missing from store"})
+			if repair {
+				if err := idx.reindexContent(path, data, meta.DocID); err != nil {
+					return fmt.Errorf("repair %s: %w", path, err)
+				}
+				report.Repaired++
+			}
+			return nil
+		}
+
+		dq := bleve.NewDocIDQuery([]string{meta.DocID})
+		res, err := idx.current().Search(bleve.NewSearchRequestOptions(dq, 1, 0, false))
+		if err != nil || res.Total == 0 {
+			report.IndexMissing = append(report.IndexMissing, VerifyIssue{Path: path, DocID: meta.DocID, Reason: "document missing from search index"})
+			if repair {
+				if err := idx.reindexContent(path, data, meta.DocID); err != nil {
+					return fmt.Errorf("repair %s: %w", path, err)
+				}
+				report.Repaired++
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if repair {
+		for _, issue := range toRemove {
+			_ = idx.Delete(issue.DocID)
+			if err := idx.store.DeleteMeta(issue.Path); err != nil {
+				return report, fmt.Errorf("remove stale metadata for %s: %w", issue.Path, err)
+			}
+			report.Repaired++
+		}
+	}
+
+	return report, nil
+}
+
+// reindexContent re-parses data (the current on-disk bytes for path) and
+// writes it back into both the store and search index under docID, then
+// refreshes the recorded content hash so later Verify/syncOnce calls see it
+// as up to date.
+func (idx *Index) reindexContent(path string, data []byte, docID string) error {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	if err := idx.Index(docID, doc); err != nil {
+		return err
+	}
+	return idx.store.PutMeta(path, FileMeta{Hash: HashContent(data), DocID: docID})
+}
+
 // SetFileMeta saves metadata for a given file path.
 func (idx *Index) SetFileMeta(path string, meta FileMeta) error {
 	return idx.store.PutMeta(path, meta)
@@ -273,13 +593,23 @@ func (idx *Index) ForEachFileMeta(fn func(path string, meta FileMeta) error) err
 	return idx.store.ForEachMeta(fn)
 }
 
+// SetCursor persists an ingestion cursor for a named upstream source.
+func (idx *Index) SetCursor(source string, cursor []byte) error {
+	return idx.store.PutCursor(source, cursor)
+}
+
+// Cursor retrieves the ingestion cursor for a named upstream source.
+func (idx *Index) Cursor(source string) ([]byte, bool, error) {
+	return idx.store.GetCursor(source)
+}
+
 // MappingJSON returns the Bleve index mapping marshaled as JSON.
 func (idx *Index) MappingJSON() ([]byte, error) {
-	m := idx.index.Mapping()
+	m := idx.current().Mapping()
 	return json.MarshalIndent(m, "", "  ")
 }
 
 // Fields returns the list of field names present in the underlying Bleve index.
 func (idx *Index) Fields() ([]string, error) {
-	return idx.index.Fields()
+	return idx.current().Fields()
 }
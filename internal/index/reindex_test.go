@@ -0,0 +1,117 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReindexSwapsInPlaceAndPreservesData(t *testing.T) {
+	idx := newTestIndex(t)
+
+	for i := 0; i < 3; i++ {
+		id := "CVE-TEST-" + string(rune('A'+i))
+		rec := sampleRecord(id, time.Now(), "title-"+id, 5.0)
+		if err := idx.Index(id, rec); err != nil {
+			t.Fatalf("index %s: %v", id, err)
+		}
+	}
+
+	var progressCalls []int
+	err := idx.Reindex(context.Background(), func(done, total int) {
+		progressCalls = append(progressCalls, done)
+	})
+	if err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	if len(progressCalls) != 3 {
+		t.Fatalf("expected 3 progress callbacks, got %d", len(progressCalls))
+	}
+
+	count, err := idx.Count()
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 documents after reindex, got %d", count)
+	}
+
+	res, err := idx.Search("title-CVE-TEST-A")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if res.Total == 0 {
+		t.Fatalf("expected the rebuilt index to still be searchable for pre-reindex data")
+	}
+
+	if _, err := os.Stat(idx.path + tmpCreateSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected no leftover %s directory, stat err = %v", tmpCreateSuffix, err)
+	}
+	if _, err := os.Stat(idx.path + tmpDeleteSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected no leftover %s directory, stat err = %v", tmpDeleteSuffix, err)
+	}
+}
+
+func TestReindexCancelledLeavesExistingIndexUntouched(t *testing.T) {
+	idx := newTestIndex(t)
+
+	if err := idx.Index("CVE-TEST-A", sampleRecord("CVE-TEST-A", time.Now(), "title-before", 5.0)); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := idx.Reindex(ctx, nil); err == nil {
+		t.Fatalf("expected Reindex to fail with an already-cancelled context")
+	}
+
+	count, err := idx.Count()
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected the pre-existing document to survive a cancelled rebuild, got count %d", count)
+	}
+
+	res, err := idx.Search("title-before")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if res.Total == 0 {
+		t.Fatalf("expected the original index to still be searchable after a cancelled rebuild")
+	}
+
+	if _, err := os.Stat(idx.path + tmpCreateSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected the abandoned side directory to be cleaned up, stat err = %v", err)
+	}
+}
+
+func TestNewIndexSweepsLeftoverReindexSiblings(t *testing.T) {
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "bleve")
+
+	// Simulate a crash between Reindex's two renames: a stale side
+	// directory left next to where the real index will live.
+	if err := os.MkdirAll(indexPath+tmpCreateSuffix, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", tmpCreateSuffix, err)
+	}
+	if err := os.MkdirAll(indexPath+tmpDeleteSuffix, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", tmpDeleteSuffix, err)
+	}
+
+	idx, err := NewIndex(indexPath, filepath.Join(dir, "store.db"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+
+	if _, err := os.Stat(indexPath + tmpCreateSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected leftover %s to be swept on startup, stat err = %v", tmpCreateSuffix, err)
+	}
+	if _, err := os.Stat(indexPath + tmpDeleteSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected leftover %s to be swept on startup, stat err = %v", tmpDeleteSuffix, err)
+	}
+}
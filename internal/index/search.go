@@ -0,0 +1,227 @@
+package index
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cveapi/internal/files"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// cvssScoreField and friends name the Bleve fields the mapping in
+// buildMapping registers, so SearchQuery and buildMapping never drift apart.
+const (
+	cvssScoreField     = "containers.cna.metrics.cvssV3_1.baseScore"
+	cvssSeverityField  = "containers.cna.metrics.cvssV3_1.baseSeverity"
+	vendorField        = "containers.cna.affected.vendor"
+	productField       = "containers.cna.affected.product"
+	cweField           = "containers.cna.problemTypes.descriptions.cweId"
+	assignerField      = "cveMetadata.assignerShortName"
+	datePublishedField = "cveMetadata.datePublished"
+	dateUpdatedField   = "cveMetadata.dateUpdated"
+)
+
+// SearchQuery describes a structured CVE search beyond a single free-text
+// query string: CVSS/date/vendor/product/CWE/assigner filters, pagination,
+// and a stable sort order.
+type SearchQuery struct {
+	// Text is matched against the default query-string query, same as
+	// Index.Search. Leave empty to filter only, matching everything that
+	// satisfies the other fields.
+	Text string
+
+	// CVSSMin/CVSSMax bound containers.cna.metrics.cvssV3_1.baseScore. Nil
+	// means unbounded on that side.
+	CVSSMin *float64
+	CVSSMax *float64
+
+	// Severity restricts results to one or more CVSS base-severity buckets
+	// (e.g. "HIGH", "CRITICAL"). Matching is case-insensitive.
+	Severity []string
+
+	// PublishedAfter/PublishedBefore bound cveMetadata.datePublished. Zero
+	// values mean unbounded on that side.
+	PublishedAfter  time.Time
+	PublishedBefore time.Time
+
+	Vendor   string
+	Product  string
+	CWE      string
+	Assigner string
+	Tags     []string
+
+	// From/Size page through results, same semantics as bleve.SearchRequestOptions.
+	From int
+	Size int
+
+	// Sort is a bleve sort string, e.g. "-cveMetadata.datePublished" or
+	// "-containers.cna.metrics.cvssV3_1.baseScore". Empty means relevance
+	// order. "-datePublished" and "-baseScore" are accepted as shorthand.
+	Sort string
+}
+
+// PagedResult is the paginated, faceted response to a SearchQuery.
+type PagedResult struct {
+	Total    uint64            `json:"total"`
+	From     int               `json:"from"`
+	Size     int               `json:"size"`
+	Hits     []files.CVERecord `json:"hits"`
+	Severity map[string]uint64 `json:"severityFacets,omitempty"`
+	Vendor   map[string]uint64 `json:"vendorFacets,omitempty"`
+}
+
+// compile turns a SearchQuery into a query.Query.
+func (q SearchQuery) compile() query.Query {
+	var clauses []query.Query
+
+	if strings.TrimSpace(q.Text) != "" {
+		clauses = append(clauses, bleve.NewQueryStringQuery(q.Text))
+	}
+
+	if q.CVSSMin != nil || q.CVSSMax != nil {
+		nq := bleve.NewNumericRangeQuery(q.CVSSMin, q.CVSSMax)
+		nq.SetField(cvssScoreField)
+		clauses = append(clauses, nq)
+	}
+
+	if len(q.Severity) > 0 {
+		var sevClauses []query.Query
+		for _, s := range q.Severity {
+			tq := bleve.NewTermQuery(strings.ToUpper(strings.TrimSpace(s)))
+			tq.SetField(cvssSeverityField)
+			sevClauses = append(sevClauses, tq)
+		}
+		clauses = append(clauses, bleve.NewDisjunctionQuery(sevClauses...))
+	}
+
+	if !q.PublishedAfter.IsZero() || !q.PublishedBefore.IsZero() {
+		var start, end *time.Time
+		if !q.PublishedAfter.IsZero() {
+			start = &q.PublishedAfter
+		}
+		if !q.PublishedBefore.IsZero() {
+			end = &q.PublishedBefore
+		}
+		dq := bleve.NewDateRangeQuery(derefTime(start), derefTime(end))
+		dq.SetField(datePublishedField)
+		clauses = append(clauses, dq)
+	}
+
+	addTerm := func(field, value string) {
+		if value == "" {
+			return
+		}
+		tq := bleve.NewTermQuery(value)
+		tq.SetField(field)
+		clauses = append(clauses, tq)
+	}
+	addTerm(vendorField, q.Vendor)
+	addTerm(productField, q.Product)
+	addTerm(cweField, q.CWE)
+	addTerm(assignerField, q.Assigner)
+
+	for _, tag := range q.Tags {
+		if tag == "" {
+			continue
+		}
+		tq := bleve.NewTermQuery(tag)
+		tq.SetField("containers.cna.references.tags")
+		clauses = append(clauses, tq)
+	}
+
+	if len(clauses) == 0 {
+		return bleve.NewMatchAllQuery()
+	}
+	return bleve.NewConjunctionQuery(clauses...)
+}
+
+// derefTime returns the zero time.Time for a nil pointer so NewDateRangeQuery
+// (which treats a zero time as "unbounded") gets what it expects.
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// resolveSort expands the "-datePublished"/"-baseScore" shorthand into the
+// fully qualified Bleve field names, and passes anything else through.
+func resolveSort(sort string) []string {
+	if sort == "" {
+		return nil
+	}
+	switch sort {
+	case "-datePublished":
+		return []string{"-" + datePublishedField}
+	case "datePublished":
+		return []string{datePublishedField}
+	case "-baseScore":
+		return []string{"-" + cvssScoreField}
+	case "baseScore":
+		return []string{cvssScoreField}
+	default:
+		return []string{sort}
+	}
+}
+
+// SearchQuery runs a structured query against the index, returning a
+// paginated, faceted result. This is the entry point for CVSS/date/vendor
+// filtering that Search's plain query-string can't express.
+func (idx *Index) SearchQuery(q SearchQuery) (*PagedResult, error) {
+	size := q.Size
+	if size <= 0 {
+		size = 50
+	}
+
+	req := bleve.NewSearchRequestOptions(q.compile(), size, q.From, false)
+	if sort := resolveSort(q.Sort); sort != nil {
+		req.SortBy(sort)
+	}
+	req.AddFacet("severity", bleve.NewFacetRequest(cvssSeverityField, 10))
+	req.AddFacet("vendor", bleve.NewFacetRequest(vendorField, 10))
+
+	res, err := idx.current().Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("structured search: %w", err)
+	}
+
+	out := &PagedResult{
+		Total: res.Total,
+		From:  q.From,
+		Size:  size,
+	}
+
+	for _, hit := range res.Hits {
+		b, err := idx.store.Get(hit.ID)
+		if err != nil {
+			continue
+		}
+		var rec files.CVERecord
+		if err := json.Unmarshal(b, &rec); err != nil {
+			continue
+		}
+		out.Hits = append(out.Hits, rec)
+	}
+
+	if fr, ok := res.Facets["severity"]; ok {
+		out.Severity = termFacetCounts(fr)
+	}
+	if fr, ok := res.Facets["vendor"]; ok {
+		out.Vendor = termFacetCounts(fr)
+	}
+
+	return out, nil
+}
+
+func termFacetCounts(fr *search.FacetResult) map[string]uint64 {
+	counts := make(map[string]uint64, len(fr.Terms.Terms()))
+	for _, t := range fr.Terms.Terms() {
+		counts[t.Term] = uint64(t.Count)
+	}
+	return counts
+}
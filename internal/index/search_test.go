@@ -0,0 +1,279 @@
+package index
+
+import (
+	"testing"
+	"time"
+
+	"cveapi/internal/files"
+)
+
+// fullRecord builds a CVERecord exercising every field SearchQuery can
+// filter on (CVSS score/severity, vendor/product, CWE, assigner,
+// reference tags, publication date), unlike sampleRecord's minimal shape.
+func fullRecord(id, title string, published time.Time, score float64, severity, vendor, product, cwe, assigner string, tags ...string) files.CVERecord {
+	return files.CVERecord{
+		DataType:    "CVE_RECORD",
+		DataVersion: "5.0",
+		CveMetadata: files.CVEMetadata{
+			CveID:             id,
+			AssignerShortName: assigner,
+			DatePublished:     files.LocalTime{Time: published},
+			DateUpdated:       files.LocalTime{Time: published},
+		},
+		Containers: files.Containers{
+			CNA: files.CNA{
+				Title:        title,
+				Descriptions: []files.LocalizedDescription{{Lang: "en", Value: title}},
+				Metrics: []files.Metric{{CVSSV31: &files.CVSS{
+					Version:      "3.1",
+					BaseScore:    score,
+					BaseSeverity: severity,
+				}}},
+				Affected: []files.Affected{{Vendor: vendor, Product: product}},
+				ProblemTypes: []files.ProblemType{{Descriptions: []files.ProblemTypeDescription{
+					{Lang: "en", CWEID: cwe},
+				}}},
+				References: referencesWithTags(tags),
+			},
+		},
+	}
+}
+
+func referencesWithTags(tags []string) []files.Reference {
+	if len(tags) == 0 {
+		return nil
+	}
+	return []files.Reference{{URL: "https://example.com/advisory", Tags: tags}}
+}
+
+func TestSearchQueryTextFiltersByFreeText(t *testing.T) {
+	idx := newTestIndex(t)
+	now := time.Now()
+	must(t, idx.Index("CVE-TEST-A", fullRecord("CVE-TEST-A", "buffer overflow in widget", now, 7.5, "HIGH", "acme", "widget", "CWE-120", "acme-psirt")))
+	must(t, idx.Index("CVE-TEST-B", fullRecord("CVE-TEST-B", "sql injection in gadget", now, 6.1, "MEDIUM", "acme", "gadget", "CWE-89", "acme-psirt")))
+
+	res, err := idx.SearchQuery(SearchQuery{Text: "overflow"})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 1 || len(res.Hits) != 1 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-A" {
+		t.Fatalf("expected only CVE-TEST-A to match, got total=%d hits=%v", res.Total, res.Hits)
+	}
+}
+
+func TestSearchQueryFiltersByCVSSRange(t *testing.T) {
+	idx := newTestIndex(t)
+	now := time.Now()
+	must(t, idx.Index("CVE-TEST-LOW", fullRecord("CVE-TEST-LOW", "low severity bug", now, 2.0, "LOW", "acme", "widget", "CWE-1", "acme-psirt")))
+	must(t, idx.Index("CVE-TEST-HIGH", fullRecord("CVE-TEST-HIGH", "high severity bug", now, 9.0, "CRITICAL", "acme", "widget", "CWE-1", "acme-psirt")))
+
+	min := 5.0
+	res, err := idx.SearchQuery(SearchQuery{CVSSMin: &min})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 1 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-HIGH" {
+		t.Fatalf("expected only CVE-TEST-HIGH above CVSSMin=%v, got total=%d hits=%v", min, res.Total, res.Hits)
+	}
+
+	max := 5.0
+	res, err = idx.SearchQuery(SearchQuery{CVSSMax: &max})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 1 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-LOW" {
+		t.Fatalf("expected only CVE-TEST-LOW below CVSSMax=%v, got total=%d hits=%v", max, res.Total, res.Hits)
+	}
+}
+
+func TestSearchQueryFiltersBySeverity(t *testing.T) {
+	idx := newTestIndex(t)
+	now := time.Now()
+	must(t, idx.Index("CVE-TEST-LOW", fullRecord("CVE-TEST-LOW", "low severity bug", now, 2.0, "LOW", "acme", "widget", "CWE-1", "acme-psirt")))
+	must(t, idx.Index("CVE-TEST-CRIT", fullRecord("CVE-TEST-CRIT", "critical bug", now, 9.8, "CRITICAL", "acme", "widget", "CWE-1", "acme-psirt")))
+
+	res, err := idx.SearchQuery(SearchQuery{Severity: []string{"critical"}})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 1 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-CRIT" {
+		t.Fatalf("expected lowercase severity to match CRITICAL case-insensitively, got total=%d hits=%v", res.Total, res.Hits)
+	}
+}
+
+func TestSearchQueryFiltersByPublishedDateRange(t *testing.T) {
+	idx := newTestIndex(t)
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	must(t, idx.Index("CVE-TEST-OLD", fullRecord("CVE-TEST-OLD", "ancient bug", old, 5.0, "MEDIUM", "acme", "widget", "CWE-1", "acme-psirt")))
+	must(t, idx.Index("CVE-TEST-NEW", fullRecord("CVE-TEST-NEW", "recent bug", recent, 5.0, "MEDIUM", "acme", "widget", "CWE-1", "acme-psirt")))
+
+	res, err := idx.SearchQuery(SearchQuery{PublishedAfter: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 1 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-NEW" {
+		t.Fatalf("expected only CVE-TEST-NEW after PublishedAfter, got total=%d hits=%v", res.Total, res.Hits)
+	}
+
+	res, err = idx.SearchQuery(SearchQuery{PublishedBefore: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 1 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-OLD" {
+		t.Fatalf("expected only CVE-TEST-OLD before PublishedBefore, got total=%d hits=%v", res.Total, res.Hits)
+	}
+}
+
+func TestSearchQueryFiltersByVendorProductCWEAndAssigner(t *testing.T) {
+	idx := newTestIndex(t)
+	now := time.Now()
+	must(t, idx.Index("CVE-TEST-A", fullRecord("CVE-TEST-A", "bug a", now, 5.0, "MEDIUM", "acme", "widget", "CWE-79", "acme-psirt")))
+	must(t, idx.Index("CVE-TEST-B", fullRecord("CVE-TEST-B", "bug b", now, 5.0, "MEDIUM", "globex", "gizmo", "CWE-89", "globex-psirt")))
+
+	cases := []struct {
+		name string
+		q    SearchQuery
+		want string
+	}{
+		{"vendor", SearchQuery{Vendor: "globex"}, "CVE-TEST-B"},
+		{"product", SearchQuery{Product: "widget"}, "CVE-TEST-A"},
+		{"cwe", SearchQuery{CWE: "CWE-89"}, "CVE-TEST-B"},
+		{"assigner", SearchQuery{Assigner: "acme-psirt"}, "CVE-TEST-A"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := idx.SearchQuery(tc.q)
+			if err != nil {
+				t.Fatalf("SearchQuery: %v", err)
+			}
+			if res.Total != 1 || res.Hits[0].CveMetadata.CveID != tc.want {
+				t.Fatalf("expected only %s, got total=%d hits=%v", tc.want, res.Total, res.Hits)
+			}
+		})
+	}
+}
+
+func TestSearchQueryFiltersByTags(t *testing.T) {
+	idx := newTestIndex(t)
+	now := time.Now()
+	must(t, idx.Index("CVE-TEST-A", fullRecord("CVE-TEST-A", "bug a", now, 5.0, "MEDIUM", "acme", "widget", "CWE-1", "acme-psirt", "exploit")))
+	must(t, idx.Index("CVE-TEST-B", fullRecord("CVE-TEST-B", "bug b", now, 5.0, "MEDIUM", "acme", "widget", "CWE-1", "acme-psirt", "patch")))
+
+	res, err := idx.SearchQuery(SearchQuery{Tags: []string{"exploit"}})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 1 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-A" {
+		t.Fatalf("expected only CVE-TEST-A tagged exploit, got total=%d hits=%v", res.Total, res.Hits)
+	}
+}
+
+func TestSearchQueryPaginatesWithFromAndSize(t *testing.T) {
+	idx := newTestIndex(t)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		id := "CVE-TEST-" + string(rune('A'+i))
+		must(t, idx.Index(id, fullRecord(id, "bug "+id, now, 5.0, "MEDIUM", "acme", "widget", "CWE-1", "acme-psirt")))
+	}
+
+	res, err := idx.SearchQuery(SearchQuery{Size: 2})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Total != 5 || len(res.Hits) != 2 || res.Size != 2 || res.From != 0 {
+		t.Fatalf("expected a 2-hit first page of 5 total, got total=%d size=%d from=%d hits=%d", res.Total, res.Size, res.From, len(res.Hits))
+	}
+
+	res, err = idx.SearchQuery(SearchQuery{From: 4, Size: 2})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if len(res.Hits) != 1 || res.From != 4 {
+		t.Fatalf("expected 1 hit on the last page starting at From=4, got from=%d hits=%d", res.From, len(res.Hits))
+	}
+}
+
+func TestSearchQueryDefaultsSizeWhenUnset(t *testing.T) {
+	idx := newTestIndex(t)
+	must(t, idx.Index("CVE-TEST-A", fullRecord("CVE-TEST-A", "bug a", time.Now(), 5.0, "MEDIUM", "acme", "widget", "CWE-1", "acme-psirt")))
+
+	res, err := idx.SearchQuery(SearchQuery{})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Size != 50 {
+		t.Fatalf("expected the default page size of 50, got %d", res.Size)
+	}
+}
+
+func TestSearchQuerySortsByShorthandAndFullFieldName(t *testing.T) {
+	idx := newTestIndex(t)
+	older := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	must(t, idx.Index("CVE-TEST-OLD", fullRecord("CVE-TEST-OLD", "old bug", older, 3.0, "LOW", "acme", "widget", "CWE-1", "acme-psirt")))
+	must(t, idx.Index("CVE-TEST-NEW", fullRecord("CVE-TEST-NEW", "new bug", newer, 9.0, "CRITICAL", "acme", "widget", "CWE-1", "acme-psirt")))
+
+	res, err := idx.SearchQuery(SearchQuery{Sort: "-datePublished"})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if len(res.Hits) != 2 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-NEW" {
+		t.Fatalf("expected -datePublished shorthand to sort newest first, got %v", res.Hits)
+	}
+
+	res, err = idx.SearchQuery(SearchQuery{Sort: "-" + cvssScoreField})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if len(res.Hits) != 2 || res.Hits[0].CveMetadata.CveID != "CVE-TEST-NEW" {
+		t.Fatalf("expected the fully-qualified score field to sort highest first, got %v", res.Hits)
+	}
+}
+
+func TestSearchQueryReturnsSeverityAndVendorFacets(t *testing.T) {
+	idx := newTestIndex(t)
+	now := time.Now()
+	must(t, idx.Index("CVE-TEST-A", fullRecord("CVE-TEST-A", "bug a", now, 9.0, "CRITICAL", "acme", "widget", "CWE-1", "acme-psirt")))
+	must(t, idx.Index("CVE-TEST-B", fullRecord("CVE-TEST-B", "bug b", now, 2.0, "LOW", "globex", "gizmo", "CWE-2", "globex-psirt")))
+
+	res, err := idx.SearchQuery(SearchQuery{})
+	if err != nil {
+		t.Fatalf("SearchQuery: %v", err)
+	}
+	if res.Severity["CRITICAL"] != 1 || res.Severity["LOW"] != 1 {
+		t.Fatalf("expected one CRITICAL and one LOW severity facet bucket, got %v", res.Severity)
+	}
+	if res.Vendor["acme"] != 1 || res.Vendor["globex"] != 1 {
+		t.Fatalf("expected one acme and one globex vendor facet bucket, got %v", res.Vendor)
+	}
+}
+
+func TestResolveSortExpandsShorthand(t *testing.T) {
+	cases := map[string][]string{
+		"":               nil,
+		"-datePublished": {"-" + datePublishedField},
+		"datePublished":  {datePublishedField},
+		"-baseScore":     {"-" + cvssScoreField},
+		"baseScore":      {cvssScoreField},
+		"custom.field":   {"custom.field"},
+	}
+	for in, want := range cases {
+		got := resolveSort(in)
+		if len(got) != len(want) {
+			t.Fatalf("resolveSort(%q): expected %v, got %v", in, want, got)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("resolveSort(%q): expected %v, got %v", in, want, got)
+			}
+		}
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is wrapped by every Storage backend's Get/GetMeta when id/path
+// has no entry, so callers can errors.Is(err, ErrNotFound) instead of
+// matching backend-specific error text (a bare bolt miss, sql.ErrNoRows, a
+// blob bucket's not-exist error, ...).
+var ErrNotFound = errors.New("not found")
+
+// Storage is the persistence backend behind an Index: it stores the raw CVE
+// JSON payloads, per-file change-detection metadata, and per-source
+// ingestion cursors. Store (BoltDB) is the default implementation; Storage
+// lets NewIndex swap in others (SQLite, S3/GCS/Azure-backed blob storage)
+// without touching Index itself.
+type Storage interface {
+	Put(id string, data interface{}) error
+	Get(id string) ([]byte, error)
+	Delete(id string) error
+	ForEach(fn func(k, v []byte) error) error
+
+	PutMeta(path string, meta FileMeta) error
+	GetMeta(path string) (FileMeta, error)
+	DeleteMeta(path string) error
+	ForEachMeta(fn func(path string, meta FileMeta) error) error
+
+	// PutVersion appends an immutable version of the CVE record identified
+	// by id, keyed by at (its cveMetadata.dateUpdated). ForEachVersion
+	// replays them oldest-first, backing Index.History/GetAt/Diff.
+	PutVersion(id string, at time.Time, data []byte) error
+	ForEachVersion(id string, fn func(at time.Time, data []byte) error) error
+
+	PutCursor(source string, cursor []byte) error
+	GetCursor(source string) (cursor []byte, ok bool, err error)
+
+	Close() error
+}
+
+var _ Storage = (*Store)(nil)
+
+// OpenStorage opens a Storage backend from a DSN. Supported schemes:
+//
+//	bolt://path/to/store.db   (or a bare path, for backward compatibility)
+//	sqlite://path/to/store.db
+//	s3://bucket/prefix        (and gs://, azblob://; payloads live in the
+//	                           bucket, metadata/cursors in metaPath)
+//
+// metaPath is only consulted for blob-backed DSNs, where file metadata and
+// cursors are kept in a local BoltDB alongside the bucket.
+func OpenStorage(dsn, metaPath string) (Storage, error) {
+	scheme, rest, hasScheme := strings.Cut(dsn, "://")
+	if !hasScheme {
+		return NewStore(dsn)
+	}
+
+	switch scheme {
+	case "bolt":
+		return NewStore(rest)
+	case "sqlite":
+		return NewSQLiteStorage(rest)
+	case "s3", "gs", "azblob":
+		return NewBlobStorage(dsn, metaPath)
+	default:
+		return nil, fmt.Errorf("unsupported storage scheme %q in DSN %q", scheme, dsn)
+	}
+}
@@ -0,0 +1,145 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/azureblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+	"gocloud.dev/gcerrors"
+)
+
+// BlobStorage stores CVE JSON payloads in a Go CDK blob.Bucket (S3, GCS,
+// Azure Blob Storage, ...) while keeping file metadata and ingestion
+// cursors in a local BoltDB, mirroring the split NewIndex already makes
+// between the (large, rarely-reread) document bodies and the (small,
+// frequently-consulted) bookkeeping data.
+type BlobStorage struct {
+	bucket *blob.Bucket
+	meta   *Store
+}
+
+// NewBlobStorage opens bucketURL (e.g. "s3://my-bucket/cves") and pairs it
+// with a local BoltDB at metaPath for FileMeta/cursor bookkeeping.
+func NewBlobStorage(bucketURL, metaPath string) (*BlobStorage, error) {
+	bucket, err := blob.OpenBucket(context.Background(), bucketURL)
+	if err != nil {
+		return nil, fmt.Errorf("open bucket %s: %w", bucketURL, err)
+	}
+
+	meta, err := NewStore(metaPath)
+	if err != nil {
+		bucket.Close()
+		return nil, fmt.Errorf("open metadata store %s: %w", metaPath, err)
+	}
+
+	return &BlobStorage{bucket: bucket, meta: meta}, nil
+}
+
+// Put implements Storage.
+func (b *BlobStorage) Put(id string, data interface{}) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal CVE data: %w", err)
+	}
+	if err := b.bucket.WriteAll(context.Background(), id, bytes, nil); err != nil {
+		return fmt.Errorf("write %s to bucket: %w", id, err)
+	}
+	return nil
+}
+
+// Get implements Storage.
+func (b *BlobStorage) Get(id string) ([]byte, error) {
+	data, err := b.bucket.ReadAll(context.Background(), id)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, fmt.Errorf("read %s from bucket: %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("read %s from bucket: %w", id, err)
+	}
+	return data, nil
+}
+
+// Delete implements Storage.
+func (b *BlobStorage) Delete(id string) error {
+	if err := b.bucket.Delete(context.Background(), id); err != nil {
+		return fmt.Errorf("delete %s from bucket: %w", id, err)
+	}
+	return nil
+}
+
+// ForEach implements Storage.
+func (b *BlobStorage) ForEach(fn func(k, v []byte) error) error {
+	ctx := context.Background()
+	iter := b.bucket.List(nil)
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("list bucket objects: %w", err)
+		}
+		data, err := b.bucket.ReadAll(ctx, obj.Key)
+		if err != nil {
+			return fmt.Errorf("read %s from bucket: %w", obj.Key, err)
+		}
+		if err := fn([]byte(obj.Key), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutMeta implements Storage.
+func (b *BlobStorage) PutMeta(path string, meta FileMeta) error { return b.meta.PutMeta(path, meta) }
+
+// GetMeta implements Storage.
+func (b *BlobStorage) GetMeta(path string) (FileMeta, error) { return b.meta.GetMeta(path) }
+
+// DeleteMeta implements Storage.
+func (b *BlobStorage) DeleteMeta(path string) error { return b.meta.DeleteMeta(path) }
+
+// ForEachMeta implements Storage.
+func (b *BlobStorage) ForEachMeta(fn func(path string, meta FileMeta) error) error {
+	return b.meta.ForEachMeta(fn)
+}
+
+// PutVersion implements Storage. Version history, like FileMeta and
+// cursors, lives in the local meta store rather than the bucket.
+func (b *BlobStorage) PutVersion(id string, at time.Time, data []byte) error {
+	return b.meta.PutVersion(id, at, data)
+}
+
+// ForEachVersion implements Storage.
+func (b *BlobStorage) ForEachVersion(id string, fn func(at time.Time, data []byte) error) error {
+	return b.meta.ForEachVersion(id, fn)
+}
+
+// PutCursor implements Storage.
+func (b *BlobStorage) PutCursor(source string, cursor []byte) error {
+	return b.meta.PutCursor(source, cursor)
+}
+
+// GetCursor implements Storage.
+func (b *BlobStorage) GetCursor(source string) ([]byte, bool, error) {
+	return b.meta.GetCursor(source)
+}
+
+// Close implements Storage.
+func (b *BlobStorage) Close() error {
+	bucketErr := b.bucket.Close()
+	metaErr := b.meta.Close()
+	if bucketErr != nil {
+		return fmt.Errorf("close bucket: %w", bucketErr)
+	}
+	if metaErr != nil {
+		return fmt.Errorf("close metadata store: %w", metaErr)
+	}
+	return nil
+}
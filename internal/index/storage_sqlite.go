@@ -0,0 +1,202 @@
+package index
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // no-cgo sqlite driver
+)
+
+// SQLiteStorage is a Storage implementation backed by SQLite, suitable for
+// concurrent readers (WAL mode) without requiring cgo.
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage opens (creating if necessary) a SQLite database at path
+// and ensures its schema exists.
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite db %s: %w", path, err)
+	}
+
+	// WAL mode lets readers proceed while a writer holds the database,
+	// matching the concurrent-reader requirement this backend exists for.
+	if _, err := db.Exec(`PRAGMA journal_mode=WAL;`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("enable WAL mode on %s: %w", path, err)
+	}
+
+	schema := `
+CREATE TABLE IF NOT EXISTS cves (id TEXT PRIMARY KEY, data BLOB NOT NULL);
+CREATE TABLE IF NOT EXISTS filemeta (path TEXT PRIMARY KEY, data BLOB NOT NULL);
+CREATE TABLE IF NOT EXISTS cursors (source TEXT PRIMARY KEY, data BLOB NOT NULL);
+CREATE TABLE IF NOT EXISTS cve_versions (id TEXT NOT NULL, at_nanos INTEGER NOT NULL, data BLOB NOT NULL, PRIMARY KEY (id, at_nanos));
+`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create sqlite schema in %s: %w", path, err)
+	}
+
+	return &SQLiteStorage{db: db}, nil
+}
+
+// Put implements Storage.
+func (s *SQLiteStorage) Put(id string, data interface{}) error {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal CVE data: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO cves (id, data) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET data = excluded.data`, id, bytes)
+	return err
+}
+
+// Get implements Storage.
+func (s *SQLiteStorage) Get(id string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM cves WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("CVE %s not found: %w", id, ErrNotFound)
+	}
+	return data, err
+}
+
+// Delete implements Storage.
+func (s *SQLiteStorage) Delete(id string) error {
+	_, err := s.db.Exec(`DELETE FROM cves WHERE id = ?`, id)
+	return err
+}
+
+// ForEach implements Storage.
+func (s *SQLiteStorage) ForEach(fn func(k, v []byte) error) error {
+	rows, err := s.db.Query(`SELECT id, data FROM cves`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return err
+		}
+		if err := fn([]byte(id), data); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// PutMeta implements Storage.
+func (s *SQLiteStorage) PutMeta(path string, meta FileMeta) error {
+	bytes, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal file meta: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO filemeta (path, data) VALUES (?, ?)
+		ON CONFLICT(path) DO UPDATE SET data = excluded.data`, path, bytes)
+	return err
+}
+
+// GetMeta implements Storage.
+func (s *SQLiteStorage) GetMeta(path string) (FileMeta, error) {
+	var meta FileMeta
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM filemeta WHERE path = ?`, path).Scan(&data)
+	if err == sql.ErrNoRows {
+		return meta, fmt.Errorf("metadata for %s not found: %w", path, ErrNotFound)
+	}
+	if err != nil {
+		return meta, err
+	}
+	return meta, json.Unmarshal(data, &meta)
+}
+
+// DeleteMeta implements Storage.
+func (s *SQLiteStorage) DeleteMeta(path string) error {
+	_, err := s.db.Exec(`DELETE FROM filemeta WHERE path = ?`, path)
+	return err
+}
+
+// ForEachMeta implements Storage.
+func (s *SQLiteStorage) ForEachMeta(fn func(path string, meta FileMeta) error) error {
+	rows, err := s.db.Query(`SELECT path, data FROM filemeta`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var path string
+		var data []byte
+		if err := rows.Scan(&path, &data); err != nil {
+			return err
+		}
+		var meta FileMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return fmt.Errorf("failed to unmarshal metadata for %s: %w", path, err)
+		}
+		if err := fn(path, meta); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// PutVersion implements Storage.
+func (s *SQLiteStorage) PutVersion(id string, at time.Time, data []byte) error {
+	_, err := s.db.Exec(`INSERT INTO cve_versions (id, at_nanos, data) VALUES (?, ?, ?)
+		ON CONFLICT(id, at_nanos) DO UPDATE SET data = excluded.data`, id, at.UTC().UnixNano(), data)
+	return err
+}
+
+// ForEachVersion implements Storage.
+func (s *SQLiteStorage) ForEachVersion(id string, fn func(at time.Time, data []byte) error) error {
+	rows, err := s.db.Query(`SELECT at_nanos, data FROM cve_versions WHERE id = ? ORDER BY at_nanos ASC`, id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nanos int64
+		var data []byte
+		if err := rows.Scan(&nanos, &data); err != nil {
+			return err
+		}
+		if err := fn(time.Unix(0, nanos).UTC(), data); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// PutCursor implements Storage.
+func (s *SQLiteStorage) PutCursor(source string, cursor []byte) error {
+	_, err := s.db.Exec(`INSERT INTO cursors (source, data) VALUES (?, ?)
+		ON CONFLICT(source) DO UPDATE SET data = excluded.data`, source, cursor)
+	return err
+}
+
+// GetCursor implements Storage.
+func (s *SQLiteStorage) GetCursor(source string) ([]byte, bool, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM cursors WHERE source = ?`, source).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Close implements Storage.
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
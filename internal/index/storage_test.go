@@ -0,0 +1,144 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// storageFactories lists every Storage implementation that should pass the
+// same correctness tests, so a new backend gets full coverage just by being
+// added here.
+func storageFactories(t *testing.T) map[string]func() Storage {
+	return map[string]func() Storage{
+		"bolt": func() Storage {
+			s, err := NewStore(filepath.Join(t.TempDir(), "store.db"))
+			if err != nil {
+				t.Fatalf("NewStore: %v", err)
+			}
+			return s
+		},
+		"sqlite": func() Storage {
+			s, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "store.sqlite"))
+			if err != nil {
+				t.Fatalf("NewSQLiteStorage: %v", err)
+			}
+			return s
+		},
+	}
+}
+
+func TestStoragePutGetDelete(t *testing.T) {
+	for name, factory := range storageFactories(t) {
+		name, factory := name, factory
+		t.Run(name, func(t *testing.T) {
+			s := factory()
+			defer s.Close()
+
+			rec := map[string]string{"cveId": "CVE-TEST-1"}
+			if err := s.Put("CVE-TEST-1", rec); err != nil {
+				t.Fatalf("Put: %v", err)
+			}
+
+			data, err := s.Get("CVE-TEST-1")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if len(data) == 0 {
+				t.Fatalf("expected non-empty data")
+			}
+
+			if err := s.Delete("CVE-TEST-1"); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if _, err := s.Get("CVE-TEST-1"); err == nil {
+				t.Fatalf("expected error getting deleted record")
+			}
+		})
+	}
+}
+
+func TestStorageMetaAndCursor(t *testing.T) {
+	for name, factory := range storageFactories(t) {
+		name, factory := name, factory
+		t.Run(name, func(t *testing.T) {
+			s := factory()
+			defer s.Close()
+
+			meta := FileMeta{ModTime: 123, Size: 456, DocID: "CVE-TEST-1"}
+			if err := s.PutMeta("/tmp/CVE-TEST-1.json", meta); err != nil {
+				t.Fatalf("PutMeta: %v", err)
+			}
+			got, err := s.GetMeta("/tmp/CVE-TEST-1.json")
+			if err != nil {
+				t.Fatalf("GetMeta: %v", err)
+			}
+			if got != meta {
+				t.Fatalf("expected %+v, got %+v", meta, got)
+			}
+
+			count := 0
+			if err := s.ForEachMeta(func(path string, m FileMeta) error {
+				count++
+				return nil
+			}); err != nil {
+				t.Fatalf("ForEachMeta: %v", err)
+			}
+			if count != 1 {
+				t.Fatalf("expected 1 meta entry, got %d", count)
+			}
+
+			if err := s.DeleteMeta("/tmp/CVE-TEST-1.json"); err != nil {
+				t.Fatalf("DeleteMeta: %v", err)
+			}
+
+			if _, ok, err := s.GetCursor("nvd"); err != nil || ok {
+				t.Fatalf("expected no cursor yet, got ok=%v err=%v", ok, err)
+			}
+			if err := s.PutCursor("nvd", []byte("cursor-1")); err != nil {
+				t.Fatalf("PutCursor: %v", err)
+			}
+			cursor, ok, err := s.GetCursor("nvd")
+			if err != nil || !ok {
+				t.Fatalf("expected cursor, got ok=%v err=%v", ok, err)
+			}
+			if string(cursor) != "cursor-1" {
+				t.Fatalf("expected cursor-1, got %s", cursor)
+			}
+		})
+	}
+}
+
+func TestStorageVersions(t *testing.T) {
+	for name, factory := range storageFactories(t) {
+		name, factory := name, factory
+		t.Run(name, func(t *testing.T) {
+			s := factory()
+			defer s.Close()
+
+			base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			if err := s.PutVersion("CVE-TEST-1", base, []byte("v1")); err != nil {
+				t.Fatalf("PutVersion v1: %v", err)
+			}
+			if err := s.PutVersion("CVE-TEST-1", base.Add(time.Hour), []byte("v2")); err != nil {
+				t.Fatalf("PutVersion v2: %v", err)
+			}
+			// An unrelated id's versions must not show up in CVE-TEST-1's history.
+			if err := s.PutVersion("CVE-TEST-2", base, []byte("other")); err != nil {
+				t.Fatalf("PutVersion other: %v", err)
+			}
+
+			var got []string
+			if err := s.ForEachVersion("CVE-TEST-1", func(at time.Time, data []byte) error {
+				got = append(got, string(data))
+				return nil
+			}); err != nil {
+				t.Fatalf("ForEachVersion: %v", err)
+			}
+
+			if len(got) != 2 || got[0] != "v1" || got[1] != "v2" {
+				t.Fatalf("expected [v1 v2] in chronological order, got %v", got)
+			}
+		})
+	}
+}
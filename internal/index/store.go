@@ -1,8 +1,13 @@
 package index
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	bolt "go.etcd.io/bbolt"
 )
@@ -12,15 +17,34 @@ const (
 	CVEBucket = "cves"
 	// MetaBucket stores per-file metadata so we can detect updates/deletes
 	MetaBucket = "filemeta"
+	// CursorBucket stores per-source ingestion cursors, keyed by source name.
+	CursorBucket = "cursors"
+	// VersionBucket stores the append-only per-CVE version history backing
+	// Index.History/GetAt/Diff, keyed by versionKey(id, dateUpdated).
+	VersionBucket = "cve_versions"
 )
 
-// FileMeta tracks minimal file information for change detection.
+// FileMeta tracks minimal file information for change detection. Hash is
+// the hex-encoded sha256 of the file's contents and is authoritative for
+// deciding whether a file needs reindexing; ModTime/Size are kept for
+// diagnostics but are no longer trusted on their own, since tools like
+// `git clone` or `rsync -a` can change content while preserving mtime.
 type FileMeta struct {
 	ModTime int64  `json:"modTime"`
 	Size    int64  `json:"size"`
+	Hash    string `json:"hash,omitempty"`
 	DocID   string `json:"docId"`
 }
 
+// HashContent returns the hex-encoded sha256 of data. It is the content
+// fingerprint stored in FileMeta.Hash, computed by callers that read a
+// file's bytes (main's indexFile/syncOnce) and recomputed by Index.Verify
+// to detect drift.
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // Store represents a BoltDB-backed storage for CVE data
 type Store struct {
 	db *bolt.DB
@@ -38,7 +62,13 @@ func NewStore(path string) (*Store, error) {
 		if _, err := tx.CreateBucketIfNotExists([]byte(CVEBucket)); err != nil {
 			return err
 		}
-		_, err := tx.CreateBucketIfNotExists([]byte(MetaBucket))
+		if _, err := tx.CreateBucketIfNotExists([]byte(MetaBucket)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(CursorBucket)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists([]byte(VersionBucket))
 		return err
 	})
 	if err != nil {
@@ -77,7 +107,7 @@ func (s *Store) Get(cveID string) ([]byte, error) {
 		b := tx.Bucket([]byte(CVEBucket))
 		data = b.Get([]byte(cveID))
 		if data == nil {
-			return fmt.Errorf("CVE %s not found", cveID)
+			return fmt.Errorf("CVE %s not found: %w", cveID, ErrNotFound)
 		}
 		return nil
 	})
@@ -125,7 +155,7 @@ func (s *Store) GetMeta(path string) (FileMeta, error) {
 		b := tx.Bucket([]byte(MetaBucket))
 		data := b.Get([]byte(path))
 		if data == nil {
-			return fmt.Errorf("metadata for %s not found", path)
+			return fmt.Errorf("metadata for %s not found: %w", path, ErrNotFound)
 		}
 		return json.Unmarshal(data, &meta)
 	})
@@ -153,3 +183,78 @@ func (s *Store) ForEachMeta(fn func(path string, meta FileMeta) error) error {
 		})
 	})
 }
+
+// versionKey encodes a (cveId, dateUpdated) pair as a Bolt key whose byte
+// order matches chronological order, so a Cursor walk over a single id's
+// keys visits its versions oldest-first. dateUpdated is encoded as a
+// big-endian UnixNano rather than a formatted timestamp so the comparison
+// is exact regardless of fractional-second formatting.
+func versionKey(id string, at time.Time) []byte {
+	key := append([]byte(id), 0x00)
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(at.UTC().UnixNano()))
+	return append(key, ts[:]...)
+}
+
+// versionKeyTime recovers the timestamp encoded by versionKey from a full
+// key k, given the (id + separator) prefix it was built with.
+func versionKeyTime(k, prefix []byte) (time.Time, error) {
+	ts := k[len(prefix):]
+	if len(ts) != 8 {
+		return time.Time{}, fmt.Errorf("malformed version key %q", k)
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(ts))).UTC(), nil
+}
+
+// PutVersion appends an immutable version of a CVE record to the history
+// kept for id.
+func (s *Store) PutVersion(id string, at time.Time, data []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(VersionBucket))
+		return b.Put(versionKey(id, at), data)
+	})
+}
+
+// ForEachVersion calls fn for every version recorded for id, oldest first.
+func (s *Store) ForEachVersion(id string, fn func(at time.Time, data []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(VersionBucket))
+		c := b.Cursor()
+		prefix := append([]byte(id), 0x00)
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			at, err := versionKeyTime(k, prefix)
+			if err != nil {
+				return err
+			}
+			if err := fn(at, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PutCursor persists an opaque ingestion cursor for a named source (e.g. an
+// NVD/OSV feed puller), so a restart can resume where it left off.
+func (s *Store) PutCursor(source string, cursor []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(CursorBucket))
+		return b.Put([]byte(source), cursor)
+	})
+}
+
+// GetCursor retrieves the persisted cursor for a named source. It returns
+// ok=false if no cursor has been stored yet.
+func (s *Store) GetCursor(source string) (cursor []byte, ok bool, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(CursorBucket))
+		data := b.Get([]byte(source))
+		if data == nil {
+			return nil
+		}
+		cursor = append([]byte(nil), data...)
+		ok = true
+		return nil
+	})
+	return cursor, ok, err
+}
@@ -0,0 +1,162 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// leaseBucket holds one entry per lock key.
+const leaseBucket = "leases"
+
+// lease is the persisted state of one key's current holder.
+type lease struct {
+	Holder    string    `json:"holder"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// BoltLocker implements Locker on top of a local BoltDB file. bolt.Open
+// takes an exclusive OS flock on the file for as long as the *bolt.DB stays
+// open, so only one process can ever hold a BoltLocker on a given path at a
+// time - a second replica pointed at the same lock.db blocks on open and
+// then times out rather than contending for leases through it. That makes
+// this backend useful for local development and for a single-process
+// deployment with Lock configured defensively (e.g. ahead of a later
+// multi-replica rollout), but NOT for actual cross-process or cross-host
+// leader election: use a redis:// or etcd:// DSN (once implemented) for
+// that, since both allow concurrent clients to contend for the same lease.
+type BoltLocker struct {
+	db *bolt.DB
+}
+
+// NewBoltLocker opens (creating if needed) a BoltDB file at path to back the
+// lock. See BoltLocker's doc comment for why this only works within a
+// single process.
+func NewBoltLocker(path string) (*BoltLocker, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("open lock db %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(leaseBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create lease bucket: %w", err)
+	}
+
+	return &BoltLocker{db: db}, nil
+}
+
+// TryAcquire implements Locker.
+func (b *BoltLocker) TryAcquire(ctx context.Context, key, holder string, ttl time.Duration) (string, bool, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", false, err
+	}
+
+	acquired := false
+	now := time.Now()
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(leaseBucket))
+
+		cur, ok, err := getLease(bucket, key)
+		if err != nil {
+			return err
+		}
+
+		// Refuse only if someone else holds an unexpired lease; an expired
+		// lease (the previous holder crashed or was killed mid-index) is
+		// fair game, and a holder re-acquiring its own key is idempotent.
+		if ok && cur.Holder != holder && now.Before(cur.ExpiresAt) {
+			return nil
+		}
+
+		acquired = true
+		return putLease(bucket, key, lease{Holder: holder, Token: token, ExpiresAt: now.Add(ttl)})
+	})
+	if err != nil {
+		return "", false, err
+	}
+	if !acquired {
+		return "", false, nil
+	}
+	return token, true, nil
+}
+
+// Renew implements Locker.
+func (b *BoltLocker) Renew(ctx context.Context, key, holder, token string, ttl time.Duration) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(leaseBucket))
+
+		cur, ok, err := getLease(bucket, key)
+		if err != nil {
+			return err
+		}
+		if !ok || cur.Holder != holder || cur.Token != token {
+			return fmt.Errorf("lease for %q lost: held by %q now", key, cur.Holder)
+		}
+
+		cur.ExpiresAt = time.Now().Add(ttl)
+		return putLease(bucket, key, cur)
+	})
+}
+
+// Release implements Locker.
+func (b *BoltLocker) Release(ctx context.Context, key, holder, token string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(leaseBucket))
+
+		cur, ok, err := getLease(bucket, key)
+		if err != nil {
+			return err
+		}
+		if !ok || cur.Holder != holder || cur.Token != token {
+			// Already lost to another holder (or never held); releasing is
+			// a no-op rather than an error.
+			return nil
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// Close implements Locker.
+func (b *BoltLocker) Close() error {
+	return b.db.Close()
+}
+
+func getLease(bucket *bolt.Bucket, key string) (lease, bool, error) {
+	raw := bucket.Get([]byte(key))
+	if raw == nil {
+		return lease{}, false, nil
+	}
+	var l lease
+	if err := json.Unmarshal(raw, &l); err != nil {
+		return lease{}, false, fmt.Errorf("decode lease for %q: %w", key, err)
+	}
+	return l, true, nil
+}
+
+func putLease(bucket *bolt.Bucket, key string, l lease) error {
+	raw, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("encode lease for %q: %w", key, err)
+	}
+	return bucket.Put([]byte(key), raw)
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate fencing token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,110 @@
+package lock
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTTL is used when Elector's caller doesn't have a strong opinion on
+// lease duration: long enough to tolerate a GC pause or a slow renewal
+// round-trip, short enough that a crashed leader's replicas fail over
+// quickly.
+const DefaultTTL = 30 * time.Second
+
+// Elector campaigns for leadership of key using locker, and runs onLeader
+// for as long as it holds the lease.
+type Elector struct {
+	Locker Locker
+	Key    string
+	Holder string
+	TTL    time.Duration
+
+	// RetryInterval is how long to wait between failed campaign attempts.
+	// Defaults to TTL/2 if zero.
+	RetryInterval time.Duration
+}
+
+// NewElector returns an Elector that campaigns for key on locker as holder,
+// with leases of ttl (or DefaultTTL if ttl <= 0).
+func NewElector(locker Locker, key, holder string, ttl time.Duration) *Elector {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Elector{Locker: locker, Key: key, Holder: holder, TTL: ttl}
+}
+
+// Run campaigns for leadership until ctx is cancelled. Each time it wins the
+// lease it calls onLeader with a context that is cancelled the moment
+// leadership is lost (the renew goroutine failed to extend the lease before
+// it expired) or ctx itself is done, then releases the lease and, if ctx is
+// still live, campaigns again. Run returns when ctx is done.
+func (e *Elector) Run(ctx context.Context, onLeader func(leaderCtx context.Context)) error {
+	retry := e.RetryInterval
+	if retry <= 0 {
+		retry = e.TTL / 2
+	}
+
+	for {
+		token, acquired, err := e.Locker.TryAcquire(ctx, e.Key, e.Holder, e.TTL)
+		if err != nil {
+			return err
+		}
+
+		if acquired {
+			e.lead(ctx, token, onLeader)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// lead runs onLeader while refreshing token in the background, returning
+// once onLeader returns, the lease is lost, or ctx is cancelled.
+func (e *Elector) lead(ctx context.Context, token string, onLeader func(leaderCtx context.Context)) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	refreshDone := make(chan struct{})
+	go e.refresh(leaderCtx, cancel, token, refreshDone)
+
+	onLeader(leaderCtx)
+
+	cancel()
+	<-refreshDone
+
+	// Best-effort: let the next campaigner take over immediately instead of
+	// waiting out the rest of the TTL. If this fails (e.g. ctx is already
+	// done), the lease simply expires on its own.
+	_ = e.Locker.Release(context.Background(), e.Key, e.Holder, token)
+}
+
+// refresh renews token at half the TTL until leaderCtx is done. If a
+// renewal fails - the lease expired and was reclaimed before we got to it,
+// most likely because this process stalled for longer than the TTL - it
+// calls cancel so the caller's onLeader stops acting as leader.
+func (e *Elector) refresh(leaderCtx context.Context, cancel context.CancelFunc, token string, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(e.TTL / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-leaderCtx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Locker.Renew(leaderCtx, e.Key, e.Holder, token, e.TTL); err != nil {
+				cancel()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package lock provides a lease-based distributed lock used for leader
+// election across multiple cveapi replicas that point at the same
+// IndexPath/StorePath: two processes writing to the same Bleve index or
+// bolt database concurrently will race on segment/page writes, so only the
+// elected leader should run the write-side loops (initial indexing,
+// syncOnce, feed-source pulls). Followers are expected to serve read-only
+// queries against a backend that itself tolerates concurrent readers (the
+// S3/GCS/Azure blob or SQLite Storage backends added alongside pluggable
+// storage, rather than a local-disk bolt file, which bbolt locks
+// exclusively for the process that opened it).
+//
+// Locker is the backend-pluggable primitive (initially a BoltDB file lock;
+// Redis or etcd backends can implement the same interface without touching
+// Elector). Elector builds leader election - campaign, lease refresh, and
+// safe takeover of a stale lease - on top of any Locker.
+//
+// The bolt backend cannot actually arbitrate between multiple processes -
+// see BoltLocker's doc comment - so a real multi-replica deployment needs a
+// redis:// or etcd:// backend once one lands; bolt:// is for local
+// development and single-process use only.
+package lock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Locker is a lease-based mutual-exclusion primitive: at most one holder can
+// hold key at a time, and a held lease automatically expires after ttl if
+// never renewed, so a crashed holder doesn't wedge the lock forever.
+type Locker interface {
+	// TryAcquire attempts to take key for holder. It succeeds (acquired
+	// true) if nobody currently holds an unexpired lease on key, or if
+	// holder already holds it (idempotent re-acquire). On success it
+	// returns an opaque fencing token that must be presented to Renew and
+	// Release.
+	TryAcquire(ctx context.Context, key, holder string, ttl time.Duration) (token string, acquired bool, err error)
+
+	// Renew extends an already-held lease to ttl from now. It fails if
+	// token no longer matches the current holder of key - the lease
+	// expired and was reclaimed by someone else in the meantime.
+	Renew(ctx context.Context, key, holder, token string, ttl time.Duration) error
+
+	// Release gives up the lease early. It is a no-op (not an error) if the
+	// lease was already lost to another holder.
+	Release(ctx context.Context, key, holder, token string) error
+
+	Close() error
+}
+
+// NewLocker opens a Locker backend from a DSN. Supported schemes:
+//
+//	bolt://path/to/lock.db   (or a bare path, for backward compatibility)
+//
+// redis:// and etcd:// are recognized but not yet implemented; they return
+// an error rather than silently falling back to the bolt backend.
+func NewLocker(dsn string) (Locker, error) {
+	scheme, rest, hasScheme := strings.Cut(dsn, "://")
+	if !hasScheme {
+		return NewBoltLocker(dsn)
+	}
+
+	switch scheme {
+	case "bolt":
+		return NewBoltLocker(rest)
+	case "redis", "etcd":
+		return nil, fmt.Errorf("lock backend %q not yet implemented; use bolt:// (or a bare path) for now", scheme)
+	default:
+		return nil, fmt.Errorf("unsupported lock backend %q in DSN %q", scheme, dsn)
+	}
+}
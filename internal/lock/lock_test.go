@@ -0,0 +1,153 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestLocker(t *testing.T) *BoltLocker {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lock.db")
+	locker, err := NewBoltLocker(path)
+	if err != nil {
+		t.Fatalf("NewBoltLocker: %v", err)
+	}
+	t.Cleanup(func() { locker.Close() })
+	return locker
+}
+
+func TestTryAcquireExcludesOtherHolders(t *testing.T) {
+	locker := newTestLocker(t)
+	ctx := context.Background()
+
+	token, ok, err := locker.TryAcquire(ctx, "leader", "replica-a", time.Minute)
+	if err != nil || !ok || token == "" {
+		t.Fatalf("expected replica-a to acquire, got ok=%v token=%q err=%v", ok, token, err)
+	}
+
+	if _, ok, err := locker.TryAcquire(ctx, "leader", "replica-b", time.Minute); err != nil || ok {
+		t.Fatalf("expected replica-b to be refused while replica-a holds an unexpired lease, got ok=%v err=%v", ok, err)
+	}
+
+	// Re-acquiring as the current holder is idempotent.
+	if _, ok, err := locker.TryAcquire(ctx, "leader", "replica-a", time.Minute); err != nil || !ok {
+		t.Fatalf("expected replica-a to re-acquire its own lease, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestTryAcquireReclaimsExpiredLease(t *testing.T) {
+	locker := newTestLocker(t)
+	ctx := context.Background()
+
+	if _, ok, err := locker.TryAcquire(ctx, "leader", "replica-a", time.Millisecond); err != nil || !ok {
+		t.Fatalf("initial acquire: ok=%v err=%v", ok, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	token, ok, err := locker.TryAcquire(ctx, "leader", "replica-b", time.Minute)
+	if err != nil || !ok || token == "" {
+		t.Fatalf("expected replica-b to reclaim the expired lease, got ok=%v token=%q err=%v", ok, token, err)
+	}
+}
+
+func TestRenewFailsAfterLeaseIsLost(t *testing.T) {
+	locker := newTestLocker(t)
+	ctx := context.Background()
+
+	token, ok, err := locker.TryAcquire(ctx, "leader", "replica-a", time.Millisecond)
+	if err != nil || !ok {
+		t.Fatalf("initial acquire: ok=%v err=%v", ok, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, err := locker.TryAcquire(ctx, "leader", "replica-b", time.Minute); err != nil || !ok {
+		t.Fatalf("replica-b reclaim: ok=%v err=%v", ok, err)
+	}
+
+	if err := locker.Renew(ctx, "leader", "replica-a", token, time.Minute); err == nil {
+		t.Fatalf("expected replica-a's renew to fail once replica-b took over")
+	}
+}
+
+func TestReleaseIsNoOpOnceLeaseIsLost(t *testing.T) {
+	locker := newTestLocker(t)
+	ctx := context.Background()
+
+	token, ok, err := locker.TryAcquire(ctx, "leader", "replica-a", time.Millisecond)
+	if err != nil || !ok {
+		t.Fatalf("initial acquire: ok=%v err=%v", ok, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, ok, err := locker.TryAcquire(ctx, "leader", "replica-b", time.Minute); err != nil || !ok {
+		t.Fatalf("replica-b reclaim: ok=%v err=%v", ok, err)
+	}
+
+	if err := locker.Release(ctx, "leader", "replica-a", token); err != nil {
+		t.Fatalf("expected stale release to be a no-op, got err=%v", err)
+	}
+	if _, ok, err := locker.TryAcquire(ctx, "leader", "replica-c", time.Minute); err != nil || ok {
+		t.Fatalf("expected replica-b's still-live lease to survive replica-a's stale release, got ok=%v err=%v", ok, err)
+	}
+}
+
+// neverRenewingLocker wraps a Locker and always fails Renew without ever
+// forwarding the call, simulating a leader stalled long enough (a GC pause,
+// a wedged goroutine) that it never gets a renewal in before its lease
+// expires. Unlike sleeping past the TTL and hoping the real refresh loop
+// doesn't win the race, this makes the loss deterministic: the Elector's
+// first refresh at TTL/2 fails, and no lease it holds is ever extended past
+// its original TTL. TryAcquire and Release are still forwarded.
+type neverRenewingLocker struct {
+	Locker
+}
+
+func (neverRenewingLocker) Renew(ctx context.Context, key, holder, token string, ttl time.Duration) error {
+	return fmt.Errorf("renew disabled by test")
+}
+
+func TestElectorRunPromotesAndDemotesOnLeaseLoss(t *testing.T) {
+	locker := newTestLocker(t)
+
+	const ttl = 20 * time.Millisecond
+	e := NewElector(neverRenewingLocker{locker}, "leader", "replica-a", ttl)
+	e.RetryInterval = 5 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ledCh := make(chan struct{}, 1)
+	lostCh := make(chan struct{}, 1)
+
+	go func() {
+		_ = e.Run(ctx, func(leaderCtx context.Context) {
+			ledCh <- struct{}{}
+			<-leaderCtx.Done()
+			lostCh <- struct{}{}
+		})
+	}()
+
+	select {
+	case <-ledCh:
+	case <-time.After(time.Second):
+		t.Fatalf("expected to be promoted to leader")
+	}
+
+	// Renew is disabled, so the first refresh at ttl/2 fails and cancels
+	// onLeader's context; no lease replica-a takes is ever renewed, so by
+	// 2*ttl the key is free to take - no race against a real refresh loop.
+	time.Sleep(2 * ttl)
+	if _, ok, err := locker.TryAcquire(context.Background(), "leader", "intruder", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to steal the expired lease, got ok=%v err=%v", ok, err)
+	}
+
+	select {
+	case <-lostCh:
+	case <-time.After(time.Second):
+		t.Fatalf("expected onLeader's context to be cancelled once lease renewal failed")
+	}
+}
@@ -2,10 +2,19 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 )
 
+// ErrPoolClosed is wrapped by Submit/SubmitCtx when the pool's own context
+// is done - Stop or Wait was called, or the context NewPool was built from
+// was cancelled - so callers can tell "this pool isn't accepting work
+// anymore" (errors.Is(err, ErrPoolClosed)) apart from a per-call deadline
+// passed to SubmitCtx expiring.
+var ErrPoolClosed = errors.New("worker pool closed")
+
 // Task represents a job to be processed by the worker pool
 type Task struct {
 	ID       string
@@ -28,15 +37,51 @@ type Pool struct {
 	wg         sync.WaitGroup
 	ctx        context.Context
 	cancel     context.CancelFunc
+	processed  int64
+	failed     int64
+	inFlight   int64
+
+	// closeMu guards the transition of tasks from open to closed. Submit/
+	// SubmitCtx hold it for read for the duration of their select, so
+	// closeTasksLocked can't close p.tasks out from under a send that's
+	// already committed to it - closing a channel with a concurrent sender
+	// is a "send on closed channel" panic waiting to happen, not just a
+	// documented misuse. closeTasksLocked takes it for write, so it only
+	// closes once every Submit/SubmitCtx call that got in ahead of it has
+	// either completed its send or backed off onto ctx.Done()/p.ctx.Done().
+	closeMu    sync.RWMutex
+	closed     bool
+	closeTasks sync.Once
+
+	// resultsRequested is set by Results() to tell worker() that someone
+	// intends to drain the results channel themselves. Wait()-only callers
+	// never call Results(), so workers skip the send entirely instead of
+	// filling results up to queueSize and blocking - errs is how Wait()
+	// gets its answer either way.
+	resultsRequested int32
+	closeResult      sync.Once
+
+	mu   sync.Mutex
+	errs []error
 }
 
-// NewPool creates a new worker pool with the given number of workers
-func NewPool(ctx context.Context, numWorkers int, processor func(Task) error) *Pool {
+// NewPool creates a new worker pool with the given number of workers.
+// queueSize bounds how many submitted-but-not-yet-picked-up tasks (and,
+// symmetrically, produced-but-not-yet-collected results, for callers that
+// consume Results()) the pool will hold before Submit blocks. A caller
+// feeding the pool from something like filepath.Walk should pick a
+// queueSize well below the size of the tree it's walking, so the walk
+// applies backpressure instead of buffering every path in memory ahead of
+// the workers. queueSize <= 0 behaves like an unbuffered channel.
+func NewPool(ctx context.Context, numWorkers, queueSize int, processor func(Task) error) *Pool {
+	if queueSize < 0 {
+		queueSize = 0
+	}
 	ctx, cancel := context.WithCancel(ctx)
 	return &Pool{
 		numWorkers: numWorkers,
-		tasks:      make(chan Task),
-		results:    make(chan Result),
+		tasks:      make(chan Task, queueSize),
+		results:    make(chan Result, queueSize),
 		processor:  processor,
 		ctx:        ctx,
 		cancel:     cancel,
@@ -52,26 +97,111 @@ func (p *Pool) Start() {
 	}
 }
 
-// Submit adds a new task to the pool
-func (p *Pool) Submit(task Task) {
+// Submit adds a new task to the pool, blocking until a slot is free. It
+// returns an error instead of submitting if the pool's context has been
+// cancelled, rather than risking a deadlock writing to Results() the way
+// the caller-side fallback used to.
+func (p *Pool) Submit(task Task) error {
+	return p.SubmitCtx(p.ctx, task)
+}
+
+// SubmitCtx is Submit with an additional per-task deadline: it returns
+// ctx.Err() if ctx is done before a slot opens up, even if the pool itself
+// is still healthy. Useful for bounding how long a single submission is
+// allowed to wait behind a full queue.
+func (p *Pool) SubmitCtx(ctx context.Context, task Task) error {
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if p.closed {
+		return fmt.Errorf("submit task %s: %w", task.ID, ErrPoolClosed)
+	}
+
 	select {
 	case p.tasks <- task:
+		atomic.AddInt64(&p.inFlight, 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	case <-p.ctx.Done():
-		p.results <- Result{Task: task, Error: fmt.Errorf("pool is closed")}
+		return fmt.Errorf("submit task %s: %w", task.ID, ErrPoolClosed)
 	}
 }
 
-// Results returns the channel that will receive task results
+// Results returns the channel that will receive task results. A caller that
+// wants per-result progress (e.g. to drive a progress bar) should consume
+// this directly, and must start doing so before (or concurrently with) its
+// first Submit call - workers check whether Results() has been called
+// before they try to send, so calling it late can miss results already
+// processed. A caller that just wants the aggregated outcome should call
+// Wait() instead and leave Results() alone entirely.
 func (p *Pool) Results() <-chan Result {
+	atomic.StoreInt32(&p.resultsRequested, 1)
 	return p.results
 }
 
-// Stop gracefully shuts down the worker pool
+// Processed returns the number of tasks this pool has finished processing
+// (successfully or not) so far. Safe to call concurrently while workers are
+// running, for a progress reporter that doesn't want to consume Results
+// itself.
+func (p *Pool) Processed() int64 {
+	return atomic.LoadInt64(&p.processed)
+}
+
+// Failed returns the number of processed tasks whose processor returned a
+// non-nil error.
+func (p *Pool) Failed() int64 {
+	return atomic.LoadInt64(&p.failed)
+}
+
+// InFlight returns the number of tasks that have been submitted but not yet
+// finished processing.
+func (p *Pool) InFlight() int64 {
+	return atomic.LoadInt64(&p.inFlight)
+}
+
+// closeTasksLocked closes p.tasks exactly once, taking closeMu for write
+// first so it can't run concurrently with a Submit/SubmitCtx call that's
+// already past the p.closed check and committed to a send on p.tasks.
+func (p *Pool) closeTasksLocked() {
+	p.closeTasks.Do(func() {
+		p.closeMu.Lock()
+		p.closed = true
+		p.closeMu.Unlock()
+		close(p.tasks)
+	})
+}
+
+// Stop closes the task queue and blocks until every already-submitted task
+// - including everything still sitting in the buffered queue, not just
+// in-flight ones - has been processed, then closes Results() and tears down
+// the pool. It does not drop queued work: the pool's ctx (from NewPool) is
+// only cancelled once draining is done, so a worker's tasks/ctx.Done()
+// select can't race and abandon a buffered task. Callers consuming
+// Results() should keep draining it until Stop returns closes the channel.
+// To actually abandon queued work instead of draining it, cancel the ctx
+// passed to NewPool before calling Stop.
 func (p *Pool) Stop() {
+	p.closeTasksLocked()
+	p.wg.Wait()
+	p.closeResult.Do(func() { close(p.results) })
 	p.cancel()
-	close(p.tasks)
+}
+
+// Wait blocks until every submitted task has been processed, then returns
+// the aggregated errors (via errors.Join) instead of forcing the caller to
+// drain Results() itself. Do not also consume Results() alongside Wait() -
+// pick one; Wait() is for callers that only care about the final outcome,
+// and since Results() was never called, workers never attempt to send to
+// it in the first place, so there's nothing to drain.
+func (p *Pool) Wait() error {
+	p.closeTasksLocked()
 	p.wg.Wait()
-	close(p.results)
+	p.closeResult.Do(func() { close(p.results) })
+	p.cancel()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return errors.Join(p.errs...)
 }
 
 func (p *Pool) worker() {
@@ -85,7 +215,21 @@ func (p *Pool) worker() {
 			}
 
 			err := p.processor(task)
-			p.results <- Result{Task: task, Error: err}
+			atomic.AddInt64(&p.inFlight, -1)
+			atomic.AddInt64(&p.processed, 1)
+			if err != nil {
+				atomic.AddInt64(&p.failed, 1)
+				p.mu.Lock()
+				p.errs = append(p.errs, err)
+				p.mu.Unlock()
+			}
+
+			if atomic.LoadInt32(&p.resultsRequested) == 1 {
+				select {
+				case p.results <- Result{Task: task, Error: err}:
+				case <-p.ctx.Done():
+				}
+			}
 
 		case <-p.ctx.Done():
 			return
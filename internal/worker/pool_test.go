@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoolWaitAggregatesErrors(t *testing.T) {
+	pool := NewPool(context.Background(), 4, 2, func(task Task) error {
+		if task.ID == "bad" {
+			return fmt.Errorf("boom: %s", task.FilePath)
+		}
+		return nil
+	})
+	pool.Start()
+
+	for i := 0; i < 10; i++ {
+		id := "good"
+		if i%3 == 0 {
+			id = "bad"
+		}
+		if err := pool.Submit(Task{ID: id, FilePath: fmt.Sprintf("file-%d", i)}); err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+	}
+
+	err := pool.Wait()
+	if err == nil {
+		t.Fatalf("expected aggregated error, got nil")
+	}
+	if got := pool.Failed(); got != 4 {
+		t.Fatalf("expected 4 failed tasks, got %d", got)
+	}
+	if got := pool.Processed(); got != 10 {
+		t.Fatalf("expected 10 processed tasks, got %d", got)
+	}
+	if got := pool.InFlight(); got != 0 {
+		t.Fatalf("expected 0 in-flight after Wait, got %d", got)
+	}
+}
+
+func TestPoolSubmitReturnsErrorAfterStop(t *testing.T) {
+	pool := NewPool(context.Background(), 1, 0, func(task Task) error { return nil })
+	pool.Start()
+	pool.Stop()
+
+	if err := pool.Submit(Task{ID: "late"}); err == nil {
+		t.Fatalf("expected Submit to fail once the pool is stopped")
+	}
+}
+
+func TestPoolStopDrainsQueuedTasks(t *testing.T) {
+	const queueSize = 4
+	const n = 10 * queueSize
+
+	var processed int64
+	pool := NewPool(context.Background(), 2, queueSize, func(task Task) error {
+		atomic.AddInt64(&processed, 1)
+		return nil
+	})
+	pool.Start()
+
+	// Consume Results() concurrently, the same way runIndexing does, so
+	// workers sending results never block behind a full results channel.
+	done := make(chan struct{})
+	go func() {
+		for range pool.Results() {
+		}
+		close(done)
+	}()
+
+	for i := 0; i < n; i++ {
+		if err := pool.Submit(Task{ID: fmt.Sprintf("task-%d", i)}); err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+	}
+
+	pool.Stop()
+	<-done
+
+	if got := atomic.LoadInt64(&processed); got != n {
+		t.Fatalf("expected all %d queued tasks to be processed, got %d", n, got)
+	}
+	if got := pool.Processed(); got != n {
+		t.Fatalf("expected Processed()==%d, got %d", n, got)
+	}
+}
+
+func TestPoolSubmitCtxHonorsPerCallDeadline(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(context.Background(), 1, 0, func(task Task) error {
+		<-release
+		return nil
+	})
+	pool.Start()
+	defer pool.Stop()
+	defer close(release)
+
+	// Fill the single worker so the next submission has nowhere to go.
+	if err := pool.Submit(Task{ID: "occupy"}); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := pool.SubmitCtx(ctx, Task{ID: "blocked"}); err == nil {
+		t.Fatalf("expected SubmitCtx to time out while the worker is busy")
+	}
+}
@@ -4,36 +4,66 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io/fs"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"cveapi/internal/feeds"
 	"cveapi/internal/files"
 	"cveapi/internal/index"
+	"cveapi/internal/lock"
 	"cveapi/internal/worker"
 )
 
 type Config struct {
-	ServerPort  string   `json:"ServerPort"`
-	EnableTLS   bool     `json:"EnableTLS"`
-	CertFile    string   `json:"CertFile"`
-	KeyFile     string   `json:"KeyFile"`
-	BasePath    string   `json:"BasePath"`
-	IndexPath   string   `json:"IndexPath"`
-	StorePath   string   `json:"StorePath"`
-	IgnoreFiles []string `json:"IgnoreFiles,omitempty"`
-	AsyncIndex  bool     `json:"AsyncIndex,omitempty"`
+	ServerPort  string               `json:"ServerPort"`
+	EnableTLS   bool                 `json:"EnableTLS"`
+	CertFile    string               `json:"CertFile"`
+	KeyFile     string               `json:"KeyFile"`
+	BasePath    string               `json:"BasePath"`
+	IndexPath   string               `json:"IndexPath"`
+	StorePath   string               `json:"StorePath"`
+	IgnoreFiles []string             `json:"IgnoreFiles,omitempty"`
+	AsyncIndex  bool                 `json:"AsyncIndex,omitempty"`
+	Sources     []feeds.SourceConfig `json:"Sources,omitempty"`
+	WebhookURL  string               `json:"WebhookURL,omitempty"`
+	Lock        *LockConfig          `json:"Lock,omitempty"`
+}
+
+// LockConfig enables leader election across multiple cveapi replicas that
+// share an IndexPath/StorePath. When set, only the elected leader runs the
+// initial index build, the periodic sync loop, and feed-source pulls; every
+// replica still serves HTTP queries against its own *index.Index handle.
+// Leave nil for the common single-process deployment.
+type LockConfig struct {
+	// DSN selects the lock backend, e.g. "bolt:///var/lib/cveapi/lock.db"
+	// or a bare path. See lock.NewLocker for supported schemes. Note that
+	// bolt:// cannot actually arbitrate between multiple replicas - see
+	// lock.BoltLocker's doc comment - so it's only suitable for local
+	// development or a single-process deployment; an actual multi-replica
+	// rollout needs redis:// or etcd:// once one is implemented.
+	DSN string `json:"DSN"`
+	// TTL is the lease duration; it is refreshed at TTL/2 while leading and
+	// defaults to lock.DefaultTTL if zero.
+	TTL time.Duration `json:"TTL,omitempty"`
 }
 
 type Server struct {
-	config Config
-	index  *index.Index
+	config   Config
+	index    *index.Index
+	progress *ProgressReporter
 }
 
 func Contains(arr []string, comparator string) bool {
@@ -48,7 +78,17 @@ func Contains(arr []string, comparator string) bool {
 
 func (s *Server) ListCVEHandler(w http.ResponseWriter, r *http.Request) {
 	// Return top 50 latest CVEs. Prefer index-backed listing for better performance
-	records, err := s.index.ListLatest(50)
+	var changedSince time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+		changedSince = t
+	}
+
+	records, err := s.index.ListLatest(50, changedSince)
 	if err != nil {
 		// Fall back to file-based collection if index listing fails
 		recordsFile, ferr := files.CollectLatest(s.config.BasePath, 50)
@@ -155,6 +195,94 @@ func (s *Server) FindCVEHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(out)
 }
 
+// SearchHandler runs a structured index.SearchQuery built from query
+// parameters (cvssMin, cvssMax, severity, vendor, product, cwe, assigner,
+// publishedAfter, publishedBefore, from, size, sort, q) and returns a
+// paginated, faceted index.PagedResult.
+func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := parseSearchQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.index.SearchQuery(query)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("search error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("marshal error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+func parseSearchQuery(values url.Values) (index.SearchQuery, error) {
+	q := index.SearchQuery{
+		Text:     values.Get("q"),
+		Vendor:   values.Get("vendor"),
+		Product:  values.Get("product"),
+		CWE:      values.Get("cwe"),
+		Assigner: values.Get("assigner"),
+		Sort:     values.Get("sort"),
+	}
+
+	if v := values.Get("severity"); v != "" {
+		q.Severity = strings.Split(v, ",")
+	}
+
+	if v := values.Get("cvssMin"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return q, fmt.Errorf("cvssMin: %w", err)
+		}
+		q.CVSSMin = &f
+	}
+	if v := values.Get("cvssMax"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return q, fmt.Errorf("cvssMax: %w", err)
+		}
+		q.CVSSMax = &f
+	}
+
+	if v := values.Get("publishedAfter"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, fmt.Errorf("publishedAfter: %w", err)
+		}
+		q.PublishedAfter = t
+	}
+	if v := values.Get("publishedBefore"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return q, fmt.Errorf("publishedBefore: %w", err)
+		}
+		q.PublishedBefore = t
+	}
+
+	if v := values.Get("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return q, fmt.Errorf("from: %w", err)
+		}
+		q.From = n
+	}
+	if v := values.Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return q, fmt.Errorf("size: %w", err)
+		}
+		q.Size = n
+	}
+
+	return q, nil
+}
+
 // IndexMappingsHandler returns the Bleve index mapping as JSON (no external CLI).
 func (s *Server) IndexMappingsHandler(w http.ResponseWriter, r *http.Request) {
 	b, err := s.index.MappingJSON()
@@ -184,6 +312,54 @@ func (s *Server) IndexFieldsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(out)
 }
 
+// HealthzHandler is a liveness probe: it reports healthy as long as the
+// process is up and its store is open, regardless of whether indexing or
+// syncing has finished. Kubernetes (or a load balancer) should use this to
+// decide whether to restart the process, not whether to route traffic to
+// it - use ReadyzHandler for that.
+func (s *Server) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.index.Count(); err != nil {
+		http.Error(w, fmt.Sprintf("store unreachable: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	// Count only reaches the search index; a cursor lookup is a cheap read
+	// against the store itself that fails once the store has been closed.
+	if _, _, err := s.index.Cursor(""); err != nil {
+		http.Error(w, fmt.Sprintf("store unreachable: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// readyzResponse is the JSON body ReadyzHandler returns on a 503, naming
+// every subsystem that isn't ready yet.
+type readyzResponse struct {
+	NotReady []string `json:"notReady"`
+}
+
+// ReadyzHandler is a readiness probe: it reports not ready (503, with a
+// JSON body naming why) while the initial index build is still running in
+// the background (AsyncIndex: true lets the HTTP server start before that
+// finishes), while the sync loop has missed several consecutive intervals,
+// or while the store can't be reached at all. A load balancer should stop
+// sending traffic to a replica that fails this, without restarting it the
+// way a failed HealthzHandler would.
+func (s *Server) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	notReady := s.progress.Readiness()
+	if _, err := s.index.Count(); err != nil {
+		notReady = append(notReady, "store")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(notReady) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(readyzResponse{NotReady: notReady})
+		return
+	}
+	json.NewEncoder(w).Encode(readyzResponse{NotReady: []string{}})
+}
+
 func readConfigurationFile() Config {
 	config, err := os.ReadFile("config.json")
 	if err != nil {
@@ -207,9 +383,15 @@ func readConfigurationFile() Config {
 	return conf
 }
 
-func buildIndex(config *Config) *index.Index {
+// buildIndex opens (creating if needed) the index/store at config's paths
+// and, unless config.AsyncIndex is set, runs the initial indexing pass
+// synchronously. It returns an error instead of calling log.Fatalf so a
+// caller (main, or a test) can decide how to react, and so ctx being
+// cancelled mid-build (e.g. Ctrl-C) unwinds through a clean idx.Close()
+// rather than leaving a half-written bolt/bleve on disk.
+func buildIndex(ctx context.Context, config *Config, progress *ProgressReporter) (*index.Index, error) {
 	if config == nil {
-		log.Fatalf("buildIndex received nil config")
+		return nil, fmt.Errorf("buildIndex received nil config")
 	}
 
 	normalizePath := func(p string) string {
@@ -227,10 +409,10 @@ func buildIndex(config *Config) *index.Index {
 
 	config.BasePath = normalizePath(config.BasePath)
 	if config.BasePath == "" {
-		log.Fatalf("BasePath must be set")
+		return nil, fmt.Errorf("BasePath must be set")
 	}
 	if _, err := os.Stat(config.BasePath); err != nil {
-		log.Fatalf("BasePath %s is invalid: %v", config.BasePath, err)
+		return nil, fmt.Errorf("BasePath %s is invalid: %w", config.BasePath, err)
 	}
 
 	indexPath := normalizePath(config.IndexPath)
@@ -253,26 +435,43 @@ func buildIndex(config *Config) *index.Index {
 	// Create index
 	idx, err := index.NewIndex(config.IndexPath, config.StorePath)
 	if err != nil {
-		log.Fatalf("Failed to create index: %v", err)
+		return nil, fmt.Errorf("failed to create index: %w", err)
 	}
 
 	if config.AsyncIndex {
-		go runIndexing(idx, config)
-		return idx
+		go func() {
+			if err := runIndexing(ctx, idx, config, progress); err != nil && ctx.Err() == nil {
+				log.Printf("background indexing stopped: %v", err)
+			}
+		}()
+		return idx, nil
 	}
 
 	// synchronous (tests)
-	runIndexing(idx, config)
-	return idx
+	if err := runIndexing(ctx, idx, config, progress); err != nil {
+		idx.Close()
+		return nil, err
+	}
+	return idx, nil
 }
 
 // runIndexing performs the file-walking and worker-pool indexing. It logs
-// progress and errors. It may be called synchronously or in a goroutine.
-func runIndexing(idx *index.Index, config *Config) {
-	ctx, cancel := context.WithCancel(context.Background())
-	_ = cancel
+// progress and reports phase/throughput to progress (which may be nil). It
+// stops early and returns ctx.Err() if ctx is cancelled (e.g. Ctrl-C),
+// leaving whatever has already been committed to idx intact rather than
+// half-writing a bolt transaction or bleve segment. It may be called
+// synchronously or in a goroutine.
+func runIndexing(ctx context.Context, idx *index.Index, config *Config, progress *ProgressReporter) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	pool := worker.NewPool(ctx, runtime.NumCPU(), func(task worker.Task) error {
+	progress.Start(PhaseWalking, 0)
+
+	// queueSize bounds how many discovered paths can sit ahead of the
+	// workers; without it filepath.Walk would happily enumerate an entire
+	// large CVE tree into memory before a single file gets indexed.
+	const queueSize = 256
+	pool := worker.NewPool(ctx, runtime.NumCPU(), queueSize, func(task worker.Task) error {
 		return indexFile(idx, task.FilePath)
 	})
 
@@ -283,8 +482,9 @@ func runIndexing(idx *index.Index, config *Config) {
 		var errs []error
 		for result := range pool.Results() {
 			if result.Error != nil {
-				errs = append(errs, fmt.Errorf("error processing %s: %v", result.Task.ID, result.Error))
+				errs = append(errs, fmt.Errorf("error processing %s: %w", result.Task.ID, result.Error))
 			}
+			progress.Advance(result.Task.FilePath, result.Error != nil)
 		}
 		resultErrors <- errs
 	}()
@@ -292,10 +492,14 @@ func runIndexing(idx *index.Index, config *Config) {
 	indexUnderBase := strings.HasPrefix(config.IndexPath, config.BasePath+string(os.PathSeparator))
 
 	// Walk through CVE files and submit indexing tasks.
+	var discovered int64
 	walkErr := filepath.Walk(config.BasePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 
 		if info.IsDir() {
 			if indexUnderBase && path == config.IndexPath {
@@ -308,24 +512,27 @@ func runIndexing(idx *index.Index, config *Config) {
 			return nil
 		}
 
-		pool.Submit(worker.Task{
+		discovered++
+		progress.SetTotal(discovered)
+
+		return pool.Submit(worker.Task{
 			ID:       filepath.Base(path),
 			FilePath: path,
 		})
-
-		return nil
 	})
-	if walkErr != nil {
-		pool.Stop()
-		<-resultErrors
-		log.Fatalf("Failed to walk data directory: %v", walkErr)
-	}
+
+	progress.SetPhase(PhaseIndexing)
 
 	pool.Stop()
-	errors := <-resultErrors
-	if len(errors) > 0 {
-		log.Printf("Encountered %d errors during indexing:", len(errors))
-		for _, err := range errors {
+	errs := <-resultErrors
+	progress.Idle()
+
+	if walkErr != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to walk data directory: %w", walkErr)
+	}
+	if len(errs) > 0 {
+		log.Printf("Encountered %d errors during indexing:", len(errs))
+		for _, err := range errs {
 			log.Printf("  %v", err)
 		}
 	} else {
@@ -335,6 +542,8 @@ func runIndexing(idx *index.Index, config *Config) {
 			log.Printf("Index build complete. Failed to fetch document count: %v", err)
 		}
 	}
+	progress.MarkIndexBuilt()
+	return ctx.Err()
 }
 
 func indexFile(idx *index.Index, path string) error {
@@ -348,10 +557,21 @@ func indexFile(idx *index.Index, path string) error {
 		return fmt.Errorf("failed to read file %s: %w", path, err)
 	}
 
+	return indexFileData(idx, path, data, info)
+}
+
+// indexFileData parses and indexes data (already-read bytes for path),
+// then records its content hash in FileMeta. It exists so syncOnce, which
+// must read a file's bytes to hash it for change detection anyway, doesn't
+// read the same file a second time to index it.
+func indexFileData(idx *index.Index, path string, data []byte, info os.FileInfo) error {
 	var cveRecord files.CVERecord
 	if err := json.Unmarshal(data, &cveRecord); err != nil {
 		return fmt.Errorf("failed to parse JSON from %s: %w", path, err)
 	}
+	if err := cveRecord.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
 
 	docID := filepath.Base(path)
 
@@ -362,6 +582,7 @@ func indexFile(idx *index.Index, path string) error {
 	meta := index.FileMeta{
 		ModTime: info.ModTime().UnixNano(),
 		Size:    info.Size(),
+		Hash:    index.HashContent(data),
 		DocID:   docID,
 	}
 	if err := idx.SetFileMeta(path, meta); err != nil {
@@ -371,42 +592,60 @@ func indexFile(idx *index.Index, path string) error {
 	return nil
 }
 
-func syncOnce(basePath, indexPath string, idx *index.Index) error {
+func syncOnce(basePath, indexPath string, idx *index.Index, progress *ProgressReporter) error {
 	// tests expect this signature (no ignoreFiles). Default to no ignores.
+	progress.Start(PhaseSyncing, 0)
+	defer progress.Idle()
+
 	seen := make(map[string]struct{})
 	var errs []error
+	var mu sync.Mutex
+
 	indexUnderBase := strings.HasPrefix(indexPath, basePath+string(os.PathSeparator))
+	skipDir := func(path string) bool {
+		return indexUnderBase && path == indexPath
+	}
 
-	walkErr := filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			errs = append(errs, err)
-			return nil
-		}
-		if d.IsDir() {
-			if indexUnderBase && path == indexPath {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	// Stat+parse each candidate file concurrently instead of one at a time;
+	// this is the step that dominates wall-clock on the full CVE corpus.
+	walkErr := files.TraverseDirParallel(basePath, runtime.NumCPU(), skipDir, func(path string, d fs.DirEntry) error {
 		if filepath.Ext(path) != ".json" {
 			return nil
 		}
 
+		mu.Lock()
 		seen[path] = struct{}{}
+		mu.Unlock()
+		progress.Advance(path, false)
 
 		info, err := d.Info()
 		if err != nil {
+			mu.Lock()
 			errs = append(errs, fmt.Errorf("stat %s: %w", path, err))
+			mu.Unlock()
+			return nil
+		}
+
+		// Content hash, not modtime, decides whether a file changed: tools
+		// like `git clone` or `rsync -a` can rewrite bytes while preserving
+		// mtime, which a modtime+size check would silently miss.
+		data, err := os.ReadFile(path)
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("read %s: %w", path, err))
+			mu.Unlock()
 			return nil
 		}
 
 		meta, err := idx.FileMeta(path)
-		if err == nil && meta.ModTime == info.ModTime().UnixNano() && meta.Size == info.Size() {
+		if err == nil && meta.Hash == index.HashContent(data) {
 			return nil
 		}
 
-		if err := indexFile(idx, path); err != nil {
+		if err := indexFileData(idx, path, data, info); err != nil {
+			mu.Lock()
 			errs = append(errs, err)
+			mu.Unlock()
 		}
 		return nil
 	})
@@ -451,16 +690,45 @@ func syncOnce(basePath, indexPath string, idx *index.Index) error {
 	return errors.Join(errs...)
 }
 
-func startSyncLoop(ctx context.Context, basePath, indexPath string, idx *index.Index, interval time.Duration) {
+// startFeedPullers launches one goroutine per configured upstream source
+// (NVD, OSV, or a cvelistV5 GitHub mirror) that periodically pulls deltas
+// into config.BasePath. If config.WebhookURL is set, records crossing
+// feeds.DefaultSeverityThreshold fire a webhook notification.
+func startFeedPullers(ctx context.Context, config *Config, idx *index.Index) {
+	if len(config.Sources) == 0 {
+		return
+	}
+
+	var notifier feeds.Notifier
+	if config.WebhookURL != "" {
+		notifier = feeds.NewWebhookNotifier(config.WebhookURL, nil)
+	}
+
+	puller := feeds.NewPuller(config.BasePath, idx, notifier)
+	for _, src := range config.Sources {
+		src := src
+		go func() {
+			if err := puller.Run(ctx, src); err != nil && ctx.Err() == nil {
+				log.Printf("feed puller for %s stopped: %v", src.Name, err)
+			}
+		}()
+	}
+}
+
+func startSyncLoop(ctx context.Context, basePath, indexPath string, idx *index.Index, interval time.Duration, progress *ProgressReporter) {
 	if interval <= 0 {
 		interval = 15 * time.Minute
 	}
 
+	progress.SetSyncInterval(interval)
+
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
-		if err := syncOnce(basePath, indexPath, idx); err != nil {
+		err := syncOnce(basePath, indexPath, idx, progress)
+		progress.RecordSync(err)
+		if err != nil {
 			log.Printf("incremental sync finished with issues: %v", err)
 		}
 
@@ -472,58 +740,195 @@ func startSyncLoop(ctx context.Context, basePath, indexPath string, idx *index.I
 	}
 }
 
+// runVerify runs a one-shot fsck-like pass over idx and logs the drift
+// report, then (if repair is true) logs how many entries were fixed. It is
+// the implementation behind the -verify/-repair CLI flags; it never starts
+// the sync loop or HTTP server.
+func runVerify(idx *index.Index, repair bool) {
+	report, err := idx.Verify(context.Background(), repair)
+	if err != nil {
+		log.Fatalf("verify failed: %v", err)
+	}
+
+	log.Printf("verify: checked %d file(s); %d missing, %d hash drift, %d store missing, %d index missing",
+		report.Checked, len(report.Missing), len(report.HashDrift), len(report.StoreMissing), len(report.IndexMissing))
+
+	for _, issues := range [][]index.VerifyIssue{report.Missing, report.HashDrift, report.StoreMissing, report.IndexMissing} {
+		for _, issue := range issues {
+			log.Printf("  %s (doc %s): %s", issue.Path, issue.DocID, issue.Reason)
+		}
+	}
+
+	if repair {
+		log.Printf("verify: repaired %d entries", report.Repaired)
+	}
+}
+
+// hostnameOrUnknown returns os.Hostname(), falling back to a constant so a
+// lock holder ID is still unique (paired with the process's PID) even on a
+// host where the hostname lookup itself fails.
+func hostnameOrUnknown() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown-host"
+	}
+	return host
+}
+
 func main() {
+	verify := flag.Bool("verify", false, "check the store/index against the on-disk corpus, print a drift report, and exit")
+	repair := flag.Bool("repair", false, "like -verify, but also reindex or remove entries that have drifted")
+	noProgress := flag.Bool("no-progress", false, "don't draw the TTY indexing/sync progress bar")
+	silentFlag := flag.Bool("silent", false, "alias for -no-progress")
+	flag.Parse()
+
+	progress := NewProgressReporter(*noProgress || *silentFlag)
+
 	config := readConfigurationFile()
 
 	// `AsyncIndex` is now read from `config.json` (defaults to false).
 	// Set `asyncIndex: true` in your config to start the server while
 	// initial indexing runs in the background.
 
-	// Build search index
-	idx := buildIndex(&config)
+	// ctx is cancelled on SIGINT/SIGTERM and threaded through indexing, the
+	// sync loop, the feed pullers and the HTTP server's shutdown, so a
+	// Ctrl-C or `docker stop` unwinds everything in the same order main
+	// started it rather than killing the process mid-write.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Build search index. With leader election enabled, the initial walk is
+	// deferred to writeLoop below so only the elected leader performs it;
+	// every replica still opens its own handle to serve queries.
+	var idx *index.Index
+	var err error
+	if config.Lock == nil {
+		idx, err = buildIndex(ctx, &config, progress)
+	} else {
+		idx, err = index.NewIndex(config.IndexPath, config.StorePath)
+	}
+	if err != nil {
+		log.Fatalf("Failed to build index: %v", err)
+	}
 	defer idx.Close()
 
-	// Start periodic sync to pick up new/changed/deleted CVEs.
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	go startSyncLoop(ctx, config.BasePath, config.IndexPath, idx, 15*time.Minute)
+	if *verify || *repair {
+		runVerify(idx, *repair)
+		return
+	}
 
-	// Create server
-	server := &Server{
-		config: config,
-		index:  idx,
+	// writeLoop performs every write-side responsibility that would race if
+	// two replicas ran it against the same IndexPath/StorePath at once: the
+	// initial walk (only needed when it was skipped above), the periodic
+	// sync loop, and feed-source pulls. With no LockConfig it just runs
+	// once, unconditionally, matching single-process behavior.
+	writeLoop := func(leaderCtx context.Context) {
+		if config.Lock != nil {
+			if err := runIndexing(leaderCtx, idx, &config, progress); err != nil && leaderCtx.Err() == nil {
+				log.Printf("leader's initial indexing stopped: %v", err)
+				return
+			}
+		}
+
+		// Ingested records land under BasePath as plain JSON, where the
+		// sync loop below picks them up like any other file.
+		startFeedPullers(leaderCtx, &config, idx)
+
+		// onLeader is expected to block for the lifetime of leadership -
+		// lead() tears down leaderCtx (and the feed pullers above along
+		// with it) the moment this call returns. startSyncLoop already
+		// loops until leaderCtx is done, so running it synchronously here
+		// (instead of as a goroutine) is what keeps the sync loop and
+		// pullers alive for as long as this replica holds the lease.
+		startSyncLoop(leaderCtx, config.BasePath, config.IndexPath, idx, 15*time.Minute, progress)
+	}
+
+	if config.Lock == nil {
+		// writeLoop now blocks for as long as ctx is live (see comment
+		// above), so it needs its own goroutine here just like the elected
+		// leader gets one below - otherwise the HTTP server would never
+		// get started.
+		go writeLoop(ctx)
+	} else {
+		locker, err := lock.NewLocker(config.Lock.DSN)
+		if err != nil {
+			// A bolt:// DSN already held by another process is the most
+			// likely cause here, not a transient error: bbolt takes an
+			// exclusive OS file lock, so a second replica pointed at the
+			// same DSN blocks for BoltLocker's open timeout and then lands
+			// here. See README.md's "Leader election" section - bolt:// is
+			// single-process only; multi-replica deployments need a
+			// redis:// or etcd:// DSN once one is implemented.
+			log.Fatalf("Failed to open lock backend %s: %v", config.Lock.DSN, err)
+		}
+		defer locker.Close()
+
+		holder := fmt.Sprintf("%s-%d", hostnameOrUnknown(), os.Getpid())
+		elector := lock.NewElector(locker, "cveapi-writer", holder, config.Lock.TTL)
+		go func() {
+			if err := elector.Run(ctx, writeLoop); err != nil && ctx.Err() == nil {
+				log.Printf("leader election stopped: %v", err)
+			}
+		}()
 	}
 
-	// Set up routes
-	http.HandleFunc("/list", server.ListCVEHandler)
-	http.HandleFunc("/findID", server.FindCVEIDHandler)
-	http.HandleFunc("/findText", server.FindCVEHandler)
-	http.HandleFunc("/index/mappings", server.IndexMappingsHandler)
-	http.HandleFunc("/index/fields", server.IndexFieldsHandler)
+	// Create server
+	server := &Server{
+		config:   config,
+		index:    idx,
+		progress: progress,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", server.ListCVEHandler)
+	mux.HandleFunc("/findID", server.FindCVEIDHandler)
+	mux.HandleFunc("/findText", server.FindCVEHandler)
+	mux.HandleFunc("/search", server.SearchHandler)
+	mux.HandleFunc("/index/mappings", server.IndexMappingsHandler)
+	mux.HandleFunc("/index/fields", server.IndexFieldsHandler)
+	mux.HandleFunc("/index/status", progress.StatusHandler)
+	mux.HandleFunc("/healthz", server.HealthzHandler)
+	mux.HandleFunc("/readyz", server.ReadyzHandler)
 
 	// Serve OpenAPI spec and Swagger UI
-	http.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		http.ServeFile(w, r, "openapi.json")
 	})
-	http.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, "swagger.html")
 	})
 
-	log.Printf("Server port selected: %v", config.ServerPort)
-
-	if config.EnableTLS {
-		log.Println("TLS is enabled, starting HTTPS server.")
-		certFile := config.CertFile
-		keyFile := config.KeyFile
+	httpServer := &http.Server{
+		Addr:    ":" + config.ServerPort,
+		Handler: mux,
+	}
 
-		if err := http.ListenAndServeTLS(":"+config.ServerPort, certFile, keyFile, nil); err != nil {
-			log.Fatalf("HTTPS server failed: %v", err)
+	serveErrors := make(chan error, 1)
+	go func() {
+		log.Printf("Server port selected: %v", config.ServerPort)
+		if config.EnableTLS {
+			log.Println("TLS is enabled, starting HTTPS server.")
+			serveErrors <- httpServer.ListenAndServeTLS(config.CertFile, config.KeyFile)
+		} else {
+			log.Println("TLS is disabled, starting HTTP server.")
+			serveErrors <- httpServer.ListenAndServe()
 		}
-	} else {
-		log.Println("TLS is disabled, starting HTTP server.")
-		if err := http.ListenAndServe(":"+config.ServerPort, nil); err != nil {
+	}()
+
+	select {
+	case err := <-serveErrors:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("HTTP server failed: %v", err)
 		}
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining in-flight requests.")
+		stop()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP server shutdown: %v", err)
+		}
 	}
 }
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"cveapi/internal/files"
+	"cveapi/internal/index"
 )
 
 func TestBuildIndexIndexesFiles(t *testing.T) {
@@ -38,7 +44,10 @@ func TestBuildIndexIndexesFiles(t *testing.T) {
 		StorePath: filepath.Join(tmp, "store.db"),
 	}
 
-	idx := buildIndex(&conf)
+	idx, err := buildIndex(context.Background(), &conf, nil)
+	if err != nil {
+		t.Fatalf("buildIndex: %v", err)
+	}
 	t.Cleanup(func() { idx.Close() })
 
 	if conf.IndexPath == conf.BasePath {
@@ -66,6 +75,53 @@ func TestBuildIndexIndexesFiles(t *testing.T) {
 	}
 }
 
+func TestRunIndexingDrainsBacklogPastQueueSize(t *testing.T) {
+	tmp := filepath.Join("testdata", t.Name())
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatalf("mkdir tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	base := filepath.Join(tmp, "data")
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		t.Fatalf("mkdir base: %v", err)
+	}
+
+	// runIndexing's worker pool buffers 256 submitted-but-unprocessed tasks;
+	// go well past that so a regression that drops the queue on Stop()
+	// shows up as a short count instead of passing by coincidence.
+	const n = 300
+	now := time.Now()
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("CVE-2024-%05d", i)
+		writeSampleCVE(t, filepath.Join(base, id+".json"), id, id, now)
+	}
+
+	conf := Config{
+		BasePath:  base,
+		IndexPath: filepath.Join(tmp, "index"),
+		StorePath: filepath.Join(tmp, "store.db"),
+	}
+
+	idx, err := index.NewIndex(conf.IndexPath, conf.StorePath)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+
+	if err := runIndexing(context.Background(), idx, &conf, nil); err != nil {
+		t.Fatalf("runIndexing: %v", err)
+	}
+
+	count, err := idx.Count()
+	if err != nil {
+		t.Fatalf("count err: %v", err)
+	}
+	if count != n {
+		t.Fatalf("expected %d docs indexed, got %d", n, count)
+	}
+}
+
 func writeSampleCVE(t *testing.T, path, id, title string, published time.Time) {
 	t.Helper()
 	rec := files.CVERecord{
@@ -113,7 +169,10 @@ func TestSyncOnceAddsUpdatesAndDeletes(t *testing.T) {
 		StorePath: filepath.Join(tmp, "store.db"),
 	}
 
-	idx := buildIndex(&conf)
+	idx, err := buildIndex(context.Background(), &conf, nil)
+	if err != nil {
+		t.Fatalf("buildIndex: %v", err)
+	}
 	t.Cleanup(func() { idx.Close() })
 
 	// No documents initially
@@ -124,7 +183,7 @@ func TestSyncOnceAddsUpdatesAndDeletes(t *testing.T) {
 	// Add new CVE and sync
 	target := filepath.Join(base, "CVE-TEST-1.json")
 	writeSampleCVE(t, target, "CVE-TEST-1", "first-title", time.Now())
-	if err := syncOnce(conf.BasePath, conf.IndexPath, idx); err != nil {
+	if err := syncOnce(conf.BasePath, conf.IndexPath, idx, nil); err != nil {
 		t.Fatalf("syncOnce add: %v", err)
 	}
 
@@ -137,9 +196,8 @@ func TestSyncOnceAddsUpdatesAndDeletes(t *testing.T) {
 	}
 
 	// Update file contents and sync; ensure new term is searchable
-	time.Sleep(10 * time.Millisecond) // ensure modtime changes
 	writeSampleCVE(t, target, "CVE-TEST-1", "second-title", time.Now())
-	if err := syncOnce(conf.BasePath, conf.IndexPath, idx); err != nil {
+	if err := syncOnce(conf.BasePath, conf.IndexPath, idx, nil); err != nil {
 		t.Fatalf("syncOnce update: %v", err)
 	}
 
@@ -155,7 +213,7 @@ func TestSyncOnceAddsUpdatesAndDeletes(t *testing.T) {
 	if err := os.Remove(target); err != nil {
 		t.Fatalf("remove file: %v", err)
 	}
-	if err := syncOnce(conf.BasePath, conf.IndexPath, idx); err != nil {
+	if err := syncOnce(conf.BasePath, conf.IndexPath, idx, nil); err != nil {
 		t.Fatalf("syncOnce delete: %v", err)
 	}
 
@@ -167,3 +225,355 @@ func TestSyncOnceAddsUpdatesAndDeletes(t *testing.T) {
 		t.Fatalf("expected 0 docs after delete, got %d", count)
 	}
 }
+
+// TestSyncOnceDetectsContentChangeWithPreservedModTime guards against the
+// modtime+size heuristic this package used to rely on: tools like
+// `rsync -a` can rewrite a file's bytes while preserving its mtime (and, as
+// here, its size), which a modtime-only check would silently miss.
+func TestSyncOnceDetectsContentChangeWithPreservedModTime(t *testing.T) {
+	tmp := filepath.Join("testdata", t.Name())
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatalf("mkdir tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	base := filepath.Join(tmp, "data")
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		t.Fatalf("mkdir base: %v", err)
+	}
+
+	conf := Config{
+		BasePath:  base,
+		IndexPath: filepath.Join(tmp, "index"),
+		StorePath: filepath.Join(tmp, "store.db"),
+	}
+	idx, err := buildIndex(context.Background(), &conf, nil)
+	if err != nil {
+		t.Fatalf("buildIndex: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+
+	target := filepath.Join(base, "CVE-TEST-1.json")
+	writeSampleCVE(t, target, "CVE-TEST-1", "originals", time.Now())
+	if err := syncOnce(conf.BasePath, conf.IndexPath, idx, nil); err != nil {
+		t.Fatalf("syncOnce add: %v", err)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	origModTime, origSize := info.ModTime(), info.Size()
+
+	// "rewritten" is the same length as "originals", so a modtime+size
+	// comparison alone can't tell the two apart.
+	writeSampleCVE(t, target, "CVE-TEST-1", "rewritten", time.Now())
+	if err := os.Chtimes(target, origModTime, origModTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if info, err := os.Stat(target); err != nil || info.Size() != origSize {
+		t.Fatalf("test fixture requires equal-length titles; got size %d, want %d (err=%v)", info.Size(), origSize, err)
+	}
+
+	if err := syncOnce(conf.BasePath, conf.IndexPath, idx, nil); err != nil {
+		t.Fatalf("syncOnce update: %v", err)
+	}
+
+	res, err := idx.Search("rewritten")
+	if err != nil {
+		t.Fatalf("search err: %v", err)
+	}
+	if res.Total == 0 {
+		t.Fatalf("expected content-hash change detection to catch an mtime-preserving rewrite")
+	}
+}
+
+// TestIndexVerifyDetectsAndRepairsDrift exercises the fsck-like Verify path:
+// a file deleted out from under the index should be flagged (and, with
+// repair, cleaned up).
+func TestIndexVerifyDetectsAndRepairsDrift(t *testing.T) {
+	tmp := filepath.Join("testdata", t.Name())
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatalf("mkdir tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	base := filepath.Join(tmp, "data")
+	if err := os.MkdirAll(base, 0o755); err != nil {
+		t.Fatalf("mkdir base: %v", err)
+	}
+
+	conf := Config{
+		BasePath:  base,
+		IndexPath: filepath.Join(tmp, "index"),
+		StorePath: filepath.Join(tmp, "store.db"),
+	}
+	idx, err := buildIndex(context.Background(), &conf, nil)
+	if err != nil {
+		t.Fatalf("buildIndex: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+
+	target := filepath.Join(base, "CVE-TEST-1.json")
+	writeSampleCVE(t, target, "CVE-TEST-1", "original", time.Now())
+	if err := syncOnce(conf.BasePath, conf.IndexPath, idx, nil); err != nil {
+		t.Fatalf("syncOnce add: %v", err)
+	}
+
+	if err := os.Remove(target); err != nil {
+		t.Fatalf("remove file: %v", err)
+	}
+
+	report, err := idx.Verify(context.Background(), false)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if len(report.Missing) != 1 {
+		t.Fatalf("expected 1 missing entry, got %d", len(report.Missing))
+	}
+
+	report, err = idx.Verify(context.Background(), true)
+	if err != nil {
+		t.Fatalf("verify repair: %v", err)
+	}
+	if report.Repaired == 0 {
+		t.Fatalf("expected repair to remove the stale entry")
+	}
+
+	if _, err := idx.FileMeta(target); err == nil {
+		t.Fatalf("expected metadata for removed file to be cleaned up")
+	}
+}
+
+func TestParseSearchQueryParsesAllParameters(t *testing.T) {
+	values := url.Values{
+		"q":               {"overflow"},
+		"vendor":          {"acme"},
+		"product":         {"widget"},
+		"cwe":             {"CWE-79"},
+		"assigner":        {"acme-psirt"},
+		"sort":            {"-datePublished"},
+		"severity":        {"HIGH,CRITICAL"},
+		"cvssMin":         {"5.0"},
+		"cvssMax":         {"9.0"},
+		"publishedAfter":  {"2024-01-01T00:00:00Z"},
+		"publishedBefore": {"2025-01-01T00:00:00Z"},
+		"from":            {"10"},
+		"size":            {"25"},
+	}
+
+	q, err := parseSearchQuery(values)
+	if err != nil {
+		t.Fatalf("parseSearchQuery: %v", err)
+	}
+
+	if q.Text != "overflow" || q.Vendor != "acme" || q.Product != "widget" || q.CWE != "CWE-79" || q.Assigner != "acme-psirt" || q.Sort != "-datePublished" {
+		t.Fatalf("unexpected scalar fields: %+v", q)
+	}
+	if len(q.Severity) != 2 || q.Severity[0] != "HIGH" || q.Severity[1] != "CRITICAL" {
+		t.Fatalf("expected severity to split on comma, got %v", q.Severity)
+	}
+	if q.CVSSMin == nil || *q.CVSSMin != 5.0 || q.CVSSMax == nil || *q.CVSSMax != 9.0 {
+		t.Fatalf("expected cvssMin/cvssMax to parse as floats, got %v/%v", q.CVSSMin, q.CVSSMax)
+	}
+	if q.PublishedAfter.IsZero() {
+		t.Fatalf("expected publishedAfter to parse as RFC3339")
+	}
+	if q.PublishedBefore.IsZero() {
+		t.Fatalf("expected publishedBefore to parse as RFC3339")
+	}
+	if q.From != 10 || q.Size != 25 {
+		t.Fatalf("expected from=10 size=25, got from=%d size=%d", q.From, q.Size)
+	}
+}
+
+func TestParseSearchQueryRejectsInvalidParameters(t *testing.T) {
+	cases := map[string]url.Values{
+		"cvssMin":        {"cvssMin": {"not-a-number"}},
+		"cvssMax":        {"cvssMax": {"not-a-number"}},
+		"publishedAfter": {"publishedAfter": {"not-a-date"}},
+		"from":           {"from": {"not-an-int"}},
+		"size":           {"size": {"not-an-int"}},
+	}
+	for name, values := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseSearchQuery(values); err == nil {
+				t.Fatalf("expected an error for invalid %s", name)
+			}
+		})
+	}
+}
+
+func TestSearchHandlerReturnsStructuredResults(t *testing.T) {
+	tmp := filepath.Join("testdata", t.Name())
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatalf("mkdir tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	idx, err := index.NewIndex(filepath.Join(tmp, "index"), filepath.Join(tmp, "store.db"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+
+	rec := files.CVERecord{
+		DataType:    "CVE_RECORD",
+		DataVersion: "5.0",
+		CveMetadata: files.CVEMetadata{
+			CveID:         "CVE-TEST-1",
+			DatePublished: files.LocalTime{Time: time.Now()},
+			DateUpdated:   files.LocalTime{Time: time.Now()},
+		},
+		Containers: files.Containers{
+			CNA: files.CNA{
+				Title:        "widget overflow",
+				Descriptions: []files.LocalizedDescription{{Lang: "en", Value: "widget overflow"}},
+				Affected:     []files.Affected{{Vendor: "acme", Product: "widget"}},
+			},
+		},
+	}
+	if err := idx.Index(rec.CveMetadata.CveID, rec); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	server := &Server{index: idx}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?vendor=acme", nil)
+	rr := httptest.NewRecorder()
+	server.SearchHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var result index.PagedResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Total != 1 || len(result.Hits) != 1 || result.Hits[0].CveMetadata.CveID != "CVE-TEST-1" {
+		t.Fatalf("expected one matching hit for vendor=acme, got %+v", result)
+	}
+}
+
+func TestSearchHandlerRejectsInvalidQueryParam(t *testing.T) {
+	tmp := filepath.Join("testdata", t.Name())
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatalf("mkdir tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	idx, err := index.NewIndex(filepath.Join(tmp, "index"), filepath.Join(tmp, "store.db"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+
+	server := &Server{index: idx}
+
+	req := httptest.NewRequest(http.MethodGet, "/search?cvssMin=not-a-number", nil)
+	rr := httptest.NewRecorder()
+	server.SearchHandler(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid cvssMin, got %d", rr.Code)
+	}
+}
+
+func TestHealthzHandlerReportsOKWhileStoreIsOpen(t *testing.T) {
+	idx := newHandlerTestIndex(t)
+	server := &Server{index: idx}
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rr := httptest.NewRecorder()
+	server.HealthzHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 while the store is open, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestHealthzHandlerReportsUnavailableOnceStoreIsClosed(t *testing.T) {
+	tmp := filepath.Join("testdata", t.Name())
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatalf("mkdir tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	idx, err := index.NewIndex(filepath.Join(tmp, "index"), filepath.Join(tmp, "store.db"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatalf("close index: %v", err)
+	}
+	server := &Server{index: idx}
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rr := httptest.NewRecorder()
+	server.HealthzHandler(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 once the store is closed, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReadyzHandlerReportsNotReadyBeforeIndexBuilt(t *testing.T) {
+	idx := newHandlerTestIndex(t)
+	server := &Server{index: idx, progress: NewProgressReporter(true)}
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rr := httptest.NewRecorder()
+	server.ReadyzHandler(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before the initial index build finishes, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp readyzResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.NotReady) != 1 || resp.NotReady[0] != "indexing" {
+		t.Fatalf("expected notReady=[\"indexing\"], got %v", resp.NotReady)
+	}
+}
+
+func TestReadyzHandlerReportsReadyOnceIndexBuilt(t *testing.T) {
+	idx := newHandlerTestIndex(t)
+	progress := NewProgressReporter(true)
+	progress.MarkIndexBuilt()
+	server := &Server{index: idx, progress: progress}
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rr := httptest.NewRecorder()
+	server.ReadyzHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 once the index has been built, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var resp readyzResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.NotReady) != 0 {
+		t.Fatalf("expected an empty notReady list, got %v", resp.NotReady)
+	}
+}
+
+func newHandlerTestIndex(t *testing.T) *index.Index {
+	t.Helper()
+	tmp := filepath.Join("testdata", t.Name())
+	if err := os.MkdirAll(tmp, 0o755); err != nil {
+		t.Fatalf("mkdir tmp: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmp) })
+
+	idx, err := index.NewIndex(filepath.Join(tmp, "index"), filepath.Join(tmp, "store.db"))
+	if err != nil {
+		t.Fatalf("NewIndex: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+	return idx
+}
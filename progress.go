@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IndexPhase identifies what the indexing subsystem is currently doing, as
+// reported by /index/status and the operator-facing progress bar.
+type IndexPhase string
+
+const (
+	PhaseIdle     IndexPhase = "idle"
+	PhaseWalking  IndexPhase = "walking"
+	PhaseIndexing IndexPhase = "indexing"
+	PhaseSyncing  IndexPhase = "syncing"
+)
+
+// IndexStatus is the snapshot served by /index/status.
+type IndexStatus struct {
+	State       IndexPhase `json:"state"`
+	Total       int64      `json:"total"`
+	Done        int64      `json:"done"`
+	Failed      int64      `json:"failed"`
+	StartedAt   time.Time  `json:"startedAt"`
+	Throughput  float64    `json:"throughput"` // files/sec, computed from done/elapsed
+	CurrentPath string     `json:"currentPath,omitempty"`
+}
+
+// ProgressReporter tracks indexing/syncing progress for both the
+// /index/status endpoint and the operator's TTY progress bar. It is safe
+// for concurrent use by the worker pool's result-consuming goroutine. A nil
+// *ProgressReporter is valid and makes every method a no-op, so callers
+// that don't care about progress (e.g. tests) can pass nil.
+type ProgressReporter struct {
+	silent bool
+
+	mu          sync.Mutex
+	phase       IndexPhase
+	startedAt   time.Time
+	currentPath string
+
+	total  int64
+	done   int64
+	failed int64
+
+	// Readiness bookkeeping for /readyz (see Readiness). indexBuilt latches
+	// true the moment the initial walk finishes; lastSyncAt/lastSyncErr
+	// track syncOnce's most recent outcome, and syncInterval is how often
+	// the sync loop is expected to run, so a stalled loop can be detected.
+	indexBuilt   bool
+	syncInterval time.Duration
+	lastSyncAt   time.Time
+	lastSyncErr  error
+}
+
+// NewProgressReporter creates a reporter. If silent is true (-no-progress or
+// -silent), render never writes to the terminal, but /index/status still
+// reflects live progress.
+func NewProgressReporter(silent bool) *ProgressReporter {
+	return &ProgressReporter{silent: silent, phase: PhaseIdle}
+}
+
+// Start begins a new phase (walking/indexing/syncing) with a known or
+// best-effort total, resetting the done/failed counters.
+func (p *ProgressReporter) Start(phase IndexPhase, total int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.phase = phase
+	p.startedAt = time.Now()
+	p.total = total
+	p.currentPath = ""
+	p.mu.Unlock()
+	atomic.StoreInt64(&p.done, 0)
+	atomic.StoreInt64(&p.failed, 0)
+	p.render()
+}
+
+// SetPhase switches the reported phase without resetting counters, for
+// transitions like walking -> indexing within a single run.
+func (p *ProgressReporter) SetPhase(phase IndexPhase) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.phase = phase
+	p.mu.Unlock()
+	p.render()
+}
+
+// SetTotal updates the total file count, for phases (like walking) where it
+// isn't known until the walk completes.
+func (p *ProgressReporter) SetTotal(total int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.total = total
+	p.mu.Unlock()
+	p.render()
+}
+
+// Advance records one processed file, updates the currently-visible path,
+// and redraws the TTY bar.
+func (p *ProgressReporter) Advance(path string, failed bool) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.done, 1)
+	if failed {
+		atomic.AddInt64(&p.failed, 1)
+	}
+	p.mu.Lock()
+	p.currentPath = path
+	p.mu.Unlock()
+	p.render()
+}
+
+// Idle marks indexing as finished, clearing the current path and ending the
+// progress bar's line.
+func (p *ProgressReporter) Idle() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.phase = PhaseIdle
+	p.currentPath = ""
+	p.mu.Unlock()
+	p.render()
+	if !p.silent {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// missedSyncAllowance is how many sync intervals may pass without a
+// successful sync before Readiness reports "syncing" as not ready. A single
+// missed run (a transient feed-source hiccup) shouldn't flip a replica out
+// of a load balancer's rotation; several in a row means the sync loop is
+// actually stuck.
+const missedSyncAllowance = 3
+
+// MarkIndexBuilt records that the initial file-walk/index build has
+// finished (successfully or not - a failed build still means there's
+// nothing left to wait on), so Readiness stops reporting "indexing" as the
+// reason a replica isn't ready.
+func (p *ProgressReporter) MarkIndexBuilt() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.indexBuilt = true
+}
+
+// SetSyncInterval records how often the sync loop is expected to run, so
+// Readiness can tell a merely-due sync apart from a stalled one.
+func (p *ProgressReporter) SetSyncInterval(interval time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.syncInterval = interval
+	if p.lastSyncAt.IsZero() {
+		// Start the staleness clock when the loop starts, so a loop whose
+		// syncs never succeed is still reported once the allowance passes.
+		p.lastSyncAt = time.Now()
+	}
+}
+
+// RecordSync records the outcome of one syncOnce call, for Readiness's
+// "syncing" check.
+func (p *ProgressReporter) RecordSync(err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		p.lastSyncAt = time.Now()
+	}
+	p.lastSyncErr = err
+}
+
+// Readiness reports which subsystems (if any) aren't ready yet: "indexing"
+// while the initial build is still running (relevant when AsyncIndex lets
+// the HTTP server start early), and "syncing" once the sync loop has missed
+// several consecutive intervals. It does not check the store - that lives
+// outside the reporter - so callers combine it with their own check.
+func (p *ProgressReporter) Readiness() []string {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var notReady []string
+	if !p.indexBuilt {
+		notReady = append(notReady, "indexing")
+	}
+	if p.syncInterval > 0 && !p.lastSyncAt.IsZero() &&
+		time.Since(p.lastSyncAt) > p.syncInterval*missedSyncAllowance {
+		notReady = append(notReady, "syncing")
+	}
+	return notReady
+}
+
+// Status returns a point-in-time snapshot for /index/status. It is safe to
+// call on a nil reporter, returning the zero-valued "idle" status.
+func (p *ProgressReporter) Status() IndexStatus {
+	if p == nil {
+		return IndexStatus{State: PhaseIdle}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	done := atomic.LoadInt64(&p.done)
+	var throughput float64
+	if elapsed := time.Since(p.startedAt).Seconds(); elapsed > 0 {
+		throughput = float64(done) / elapsed
+	}
+
+	return IndexStatus{
+		State:       p.phase,
+		Total:       p.total,
+		Done:        done,
+		Failed:      atomic.LoadInt64(&p.failed),
+		StartedAt:   p.startedAt,
+		Throughput:  throughput,
+		CurrentPath: p.currentPath,
+	}
+}
+
+// StatusHandler serves the current IndexStatus as JSON at /index/status.
+func (p *ProgressReporter) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	out, err := json.Marshal(p.Status())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error marshaling status: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(out)
+}
+
+// render redraws the single-line TTY progress bar: [####....] done/total,
+// files/sec, ETA. It's a no-op for a silent or nil reporter.
+func (p *ProgressReporter) render() {
+	if p == nil || p.silent {
+		return
+	}
+
+	status := p.Status()
+
+	const width = 30
+	filled := width
+	if status.Total > 0 {
+		filled = int(float64(width) * float64(status.Done) / float64(status.Total))
+		if filled > width {
+			filled = width
+		}
+	}
+
+	bar := make([]byte, width)
+	for i := range bar {
+		if i < filled {
+			bar[i] = '#'
+		} else {
+			bar[i] = '.'
+		}
+	}
+
+	eta := "?"
+	if status.Throughput > 0 && status.Total > status.Done {
+		remaining := time.Duration(float64(status.Total-status.Done)/status.Throughput) * time.Second
+		eta = remaining.Truncate(time.Second).String()
+	}
+
+	fmt.Fprintf(os.Stderr, "\r[%s] %-9s %d/%d  %.1f files/sec  ETA %s   ",
+		string(bar), status.State, status.Done, status.Total, status.Throughput, eta)
+}